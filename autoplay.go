@@ -0,0 +1,100 @@
+package gavalink
+
+import (
+	"context"
+	"net/url"
+)
+
+// AutoplayProvider picks what a player plays once its queue runs dry
+type AutoplayProvider interface {
+	// Next returns the query to load after the track last played, or ""
+	// to stop playing
+	//
+	// The first track loaded which isn't in the player's history is
+	// played.
+	Next(ctx context.Context, player *Player, last TrackInfo) (string, error)
+}
+
+// AutoplayProviderFunc is a function which implements AutoplayProvider
+type AutoplayProviderFunc func(ctx context.Context, player *Player, last TrackInfo) (string, error)
+
+// Next calls the function
+func (f AutoplayProviderFunc) Next(ctx context.Context, player *Player, last TrackInfo) (string, error) {
+	return f(ctx, player, last)
+}
+
+// YouTubeMix continues with YouTube's mix of the last track
+//
+// Tracks from other sources don't continue.
+type YouTubeMix struct{}
+
+// Next returns the URL of the mix of a YouTube track
+func (YouTubeMix) Next(ctx context.Context, player *Player, last TrackInfo) (string, error) {
+	if last.SourceName != "youtube" || last.Identifier == "" {
+		return "", nil
+	}
+	query := url.Values{}
+	query.Set("v", last.Identifier)
+	query.Set("list", "RD"+last.Identifier)
+	return "https://www.youtube.com/watch?" + query.Encode(), nil
+}
+
+// SetAutoplay sets the provider continuing playback once the player's
+// queue runs dry, nil to stop at the end of the queue
+//
+// Autoplay starts once a track finished, after OnTrackEnd returned
+// without playing another track.
+func (player *Player) SetAutoplay(provider AutoplayProvider) {
+	player.mu.Lock()
+	player.autoplayProvider = provider
+	player.mu.Unlock()
+}
+
+// autoplay plays the track the autoplay provider picks after track, in
+// the background
+func (player *Player) autoplay(track string) {
+	player.mu.Lock()
+	provider, plays := player.autoplayProvider, player.plays
+	player.mu.Unlock()
+	manager := player.manager
+	if provider == nil || manager.ctx.Err() != nil {
+		return
+	}
+
+	manager.wg.Add(1)
+	go func() {
+		defer manager.wg.Done()
+		if err := player.playAutoplay(manager.ctx, provider, track, plays); err != nil {
+			player.logger().Warn("couldn't autoplay", "err", err)
+		}
+	}()
+}
+
+func (player *Player) playAutoplay(ctx context.Context, provider AutoplayProvider, track string, plays uint64) error {
+	last, err := player.manager.DecodeTrack(track)
+	if err != nil {
+		return err
+	}
+	query, err := provider.Next(ctx, player, *last)
+	if err != nil || query == "" {
+		return err
+	}
+	tracks, err := player.Node().LoadTracksContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tracks.Tracks {
+		if t.Info.Identifier == last.Identifier || player.history.Contains(t.Info.Identifier) {
+			continue
+		}
+		// something else was played, or the player stopped, while
+		// loading
+		if !player.playing(plays, "") {
+			return nil
+		}
+		return player.PlayContext(ctx, t.Data)
+	}
+	player.logger().Info("autoplay found no new track", "query", query)
+	return nil
+}
@@ -0,0 +1,148 @@
+package gavalink_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/foxbot/gavalink"
+)
+
+// trackEndHandler reports the reasons tracks ended for
+type trackEndHandler struct {
+	gavalink.DummyEventHandler
+	reasons chan string
+}
+
+func (h trackEndHandler) OnTrackEnd(player *gavalink.Player, track string, reason string) error {
+	h.reasons <- reason
+	return nil
+}
+
+func TestAutoplay(t *testing.T) {
+	srv := newTestServer(t)
+
+	var mu sync.Mutex
+	var queries []string
+	loaded := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), queries...)
+	}
+	srv.Handle("/loadtracks", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.Query().Get("identifier"))
+		mu.Unlock()
+		// the mix starts with the track it's made of
+		fmt.Fprintf(w, `{"loadType":"PLAYLIST_LOADED","tracks":[
+			{"track":%q,"info":{"identifier":"hHW1oY26kxQ","sourceName":"youtube"}},
+			{"track":"next","info":{"identifier":"next","sourceName":"youtube"}}
+		]}`, testSong)
+	})
+
+	handler := trackEndHandler{reasons: make(chan string, 1)}
+	_, player := newTestPlayer(t, srv, handler)
+	player.SetAutoplay(gavalink.YouTubeMix{})
+	// trackEnd ends track, and waits until the event is handled
+	trackEnd := func(track string, reason string) {
+		srv.Send(t, fmt.Sprintf(`{"op":"event","type":"TrackEndEvent","guildId":"1","track":%q,"reason":%q}`, track, reason))
+		select {
+		case <-handler.reasons:
+		case <-time.After(2 * time.Second):
+			t.Fatal("TrackEnd wasn't handled")
+		}
+	}
+
+	if err := player.Play(testSong); err != nil {
+		t.Fatal(err)
+	}
+	// replaced tracks don't autoplay
+	trackEnd(testSong, gavalink.EndReasonReplaced)
+	trackEnd(testSong, gavalink.EndReasonFinished)
+	waitFor(t, "autoplay", func() bool { return player.Track() == "next" })
+
+	want := []string{"https://www.youtube.com/watch?list=RDhHW1oY26kxQ&v=hHW1oY26kxQ"}
+	if got := loaded(); !slices.Equal(got, want) {
+		t.Errorf("loaded %v, want %v", got, want)
+	}
+
+	// the queue plays before autoplay
+	player.Queue().Add(gavalink.Track{Data: testStream})
+	trackEnd("next", gavalink.EndReasonFinished)
+	time.Sleep(100 * time.Millisecond)
+	if got := loaded(); len(got) != 1 {
+		t.Errorf("autoplay loaded %v with a queued track", got[1:])
+	}
+}
+
+func TestAutoplayStopped(t *testing.T) {
+	srv := newTestServer(t)
+
+	loading := make(chan struct{})
+	release := make(chan struct{})
+	srv.Handle("/loadtracks", func(w http.ResponseWriter, r *http.Request) {
+		close(loading)
+		<-release
+		w.Write([]byte(`{"loadType":"TRACK_LOADED","tracks":[{"track":"next","info":{"identifier":"next"}}]}`))
+	})
+
+	_, player := newTestPlayer(t, srv, gavalink.DummyEventHandler{})
+	player.SetAutoplay(gavalink.AutoplayProviderFunc(func(ctx context.Context, player *gavalink.Player, last gavalink.TrackInfo) (string, error) {
+		return "ytsearch:" + last.Title, nil
+	}))
+	if err := player.Play(testSong); err != nil {
+		t.Fatal(err)
+	}
+	srv.Send(t, fmt.Sprintf(`{"op":"event","type":"TrackEndEvent","guildId":"1","track":%q,"reason":"FINISHED"}`, testSong))
+
+	// stopping while the next track loads cancels autoplay
+	<-loading
+	if err := player.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+
+	time.Sleep(100 * time.Millisecond)
+	if track := player.Track(); track != "" {
+		t.Errorf("autoplay played %q after Stop", track)
+	}
+}
+
+func TestYouTubeMix(t *testing.T) {
+	ctx := context.Background()
+	query, err := gavalink.YouTubeMix{}.Next(ctx, nil, gavalink.TrackInfo{SourceName: "youtube", Identifier: "abc"})
+	if want := "https://www.youtube.com/watch?list=RDabc&v=abc"; err != nil || query != want {
+		t.Errorf("got %q, %v, want %q", query, err, want)
+	}
+	query, err = gavalink.YouTubeMix{}.Next(ctx, nil, gavalink.TrackInfo{SourceName: "soundcloud", Identifier: "abc"})
+	if err != nil || query != "" {
+		t.Errorf("a SoundCloud track continued with %q, %v", query, err)
+	}
+}
+
+func TestReplacedTrackEnd(t *testing.T) {
+	srv := newTestServer(t)
+	handler := trackEndHandler{reasons: make(chan string, 1)}
+	_, player := newTestPlayer(t, srv, handler)
+
+	// playing a track again replaces it with itself
+	if err := player.Play(testSong); err != nil {
+		t.Fatal(err)
+	}
+	if err := player.Play(testSong); err != nil {
+		t.Fatal(err)
+	}
+	srv.Send(t, fmt.Sprintf(`{"op":"event","type":"TrackEndEvent","guildId":"1","track":%q,"reason":"REPLACED"}`, testSong))
+	select {
+	case <-handler.reasons:
+	case <-time.After(2 * time.Second):
+		t.Fatal("TrackEnd wasn't handled")
+	}
+	if player.Track() != testSong {
+		t.Error("the replaced play cleared the track playing again")
+	}
+}
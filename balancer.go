@@ -0,0 +1,81 @@
+package gavalink
+
+import (
+	"time"
+)
+
+// LoadBalancer picks the node new players are created on
+type LoadBalancer interface {
+	// Pick returns the best of nodes for a player in region
+	//
+	// region is empty when the player's region isn't known. nodes never
+	// contains draining nodes, and is never empty.
+	Pick(nodes []*Node, region string) (*Node, error)
+}
+
+// PenaltyBalancer picks the node with the lowest Penalty
+//
+// It is the default LoadBalancer.
+type PenaltyBalancer struct{}
+
+// Pick returns the node with the lowest Penalty for region
+func (PenaltyBalancer) Pick(nodes []*Node, region string) (*Node, error) {
+	var best *Node
+	var bestPenalty float32
+	for _, n := range nodes {
+		p := n.Penalty(region)
+		if best == nil || p < bestPenalty {
+			best, bestPenalty = n, p
+		}
+	}
+	return best, nil
+}
+
+// ReconnectPolicy decides how a disconnected node reconnects
+type ReconnectPolicy interface {
+	// Backoff returns how long to wait before the given reconnect
+	// attempt, starting at 1, and false once the node should give up and
+	// be removed
+	Backoff(attempt int) (time.Duration, bool)
+}
+
+// ExponentialBackoff retries immediately, then doubles its delay with
+// every further attempt
+type ExponentialBackoff struct {
+	// Attempts is the maximum amount of attempts
+	Attempts int
+	// Delay is the delay before the second attempt
+	Delay time.Duration
+	// MaxDelay caps the delay, if set
+	MaxDelay time.Duration
+}
+
+// DefaultReconnectPolicy is the ReconnectPolicy of managers which
+// weren't given one
+var DefaultReconnectPolicy = ExponentialBackoff{
+	Attempts: 5,
+	Delay:    time.Second,
+	MaxDelay: 30 * time.Second,
+}
+
+// Backoff implements ReconnectPolicy
+func (b ExponentialBackoff) Backoff(attempt int) (time.Duration, bool) {
+	if attempt > b.Attempts {
+		return 0, false
+	}
+	if attempt == 1 {
+		return 0, true
+	}
+
+	delay := b.Delay
+	for i := 2; i < attempt; i++ {
+		delay *= 2
+		if b.MaxDelay > 0 && delay >= b.MaxDelay {
+			break
+		}
+	}
+	if b.MaxDelay > 0 && delay > b.MaxDelay {
+		delay = b.MaxDelay
+	}
+	return delay, true
+}
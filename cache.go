@@ -0,0 +1,82 @@
+package gavalink
+
+import (
+	"container/list"
+	"sync"
+)
+
+// trackCache is a least recently used cache of decoded tracks
+type trackCache struct {
+	size int
+
+	mu    sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+}
+
+type trackCacheEntry struct {
+	track string
+	info  TrackInfo
+}
+
+func newTrackCache(size int) *trackCache {
+	if size == 0 {
+		return nil
+	}
+	return &trackCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// get returns a copy of a cached track; a nil cache never hits
+func (c *trackCache) get(track string) (*TrackInfo, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[track]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	info := e.Value.(*trackCacheEntry).info
+	return &info, true
+}
+
+func (c *trackCache) put(track string, info TrackInfo) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[track]; ok {
+		c.order.MoveToFront(e)
+		e.Value.(*trackCacheEntry).info = info
+		return
+	}
+	c.items[track] = c.order.PushFront(&trackCacheEntry{track, info})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*trackCacheEntry).track)
+	}
+}
+
+// DecodeTrack decodes a base64 Lavaplayer track, using the manager's
+// track cache if it has one
+func (lavalink *Lavalink) DecodeTrack(track string) (*TrackInfo, error) {
+	if info, ok := lavalink.cache.get(track); ok {
+		return info, nil
+	}
+	info, err := DecodeString(track)
+	if err != nil {
+		return nil, err
+	}
+	lavalink.cache.put(track, *info)
+	return info, nil
+}
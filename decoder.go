@@ -20,7 +20,34 @@ func DecodeString(data string) (*TrackInfo, error) {
 }
 
 // Decode decodes a reader into a TrackInfo
+//
+// If the track can't be decoded, a *DecodeError is returned; truncated
+// tracks fail with io.ErrUnexpectedEOF.
 func Decode(r io.Reader) (*TrackInfo, error) {
+	cr := &countingReader{r: r}
+	track, err := decode(cr)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, &DecodeError{cr.n, err}
+	}
+	return track, nil
+}
+
+// countingReader counts the bytes read from r
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+func decode(r io.Reader) (*TrackInfo, error) {
 	// https://github.com/serenity-rs/lavalink.rs/blob/master/src/decoder.rs
 
 	var value uint8
@@ -79,28 +106,20 @@ func Decode(r io.Reader) (*TrackInfo, error) {
 		return nil, err
 	}
 
+	// a missing URL takes no bytes
 	var url string
 	if hasURL == 1 {
 		url, err = readString(r)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		var size uint8
-		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
-			return nil, err
-		}
-
-		ignore := make([]byte, size)
-		if err := binary.Read(r, binary.LittleEndian, &ignore); err != nil {
-			return nil, err
-		}
 	}
 
-	/*source, err := readString(r)
+	// the source manager follows the URL, whether it's there or not
+	source, err := readString(r)
 	if err != nil {
 		return nil, err
-	}*/
+	}
 
 	track := &TrackInfo{
 		Identifier: identifier,
@@ -108,12 +127,23 @@ func Decode(r io.Reader) (*TrackInfo, error) {
 		Author:     author,
 		URI:        url,
 		Stream:     stream == 1,
-		Length:     int(length),
+		Seekable:   stream != 1,
+		Length:     trackLength(length),
+		SourceName: source,
 	}
 
 	return track, nil
 }
 
+// trackLength converts a length to millis, clamping lengths which don't
+// fit in an int, like streams', to InfiniteLength
+func trackLength(length uint64) int {
+	if length >= InfiniteLength {
+		return InfiniteLength
+	}
+	return int(length)
+}
+
 func readString(r io.Reader) (string, error) {
 	var size uint16
 	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
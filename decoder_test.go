@@ -1,6 +1,11 @@
 package gavalink_test
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
 	"testing"
 
 	"github.com/foxbot/gavalink"
@@ -14,4 +19,50 @@ func TestDecoder(t *testing.T) {
 		return
 	}
 	t.Log(track)
+	if track.SourceName != "youtube" {
+		t.Errorf("got source %q, want youtube", track.SourceName)
+	}
+}
+
+func TestDecoderWithoutURL(t *testing.T) {
+	var b bytes.Buffer
+	writeString := func(s string) {
+		binary.Write(&b, binary.BigEndian, uint16(len(s)))
+		b.WriteString(s)
+	}
+	// header and version
+	b.Write([]byte{0x40, 0, 0, 0, 2})
+	writeString("title")
+	writeString("author")
+	binary.Write(&b, binary.BigEndian, uint64(215000))
+	writeString("identifier")
+	// not a stream, and no URL
+	b.Write([]byte{0, 0})
+	writeString("soundcloud")
+	// position
+	b.Write(make([]byte, 8))
+
+	track, err := gavalink.DecodeString(base64.StdEncoding.EncodeToString(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if track.URI != "" || track.SourceName != "soundcloud" || track.Length != 215000 {
+		t.Errorf("got URI %q, source %q and length %d", track.URI, track.SourceName, track.Length)
+	}
+}
+
+func TestDecoderTruncated(t *testing.T) {
+	data := "QAAAkAIALGxvZmkgaGlwIGhvcCByYWRpbyAtIGJlYXRzIHRvIHJlbGF4L3N0dWR5IHRv"
+	_, err := gavalink.DecodeString(data)
+
+	var decodeErr *gavalink.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("got %v, want a *DecodeError", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got %v, want io.ErrUnexpectedEOF", err)
+	}
+	if decodeErr.Offset == 0 {
+		t.Error("DecodeError has no offset")
+	}
 }
@@ -0,0 +1,98 @@
+package gavalink
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// InfiniteLength is the Length of tracks which never end, like most
+	// streams
+	//
+	// Lavaplayer reports the largest length it can for them; larger
+	// lengths are clamped to InfiniteLength.
+	InfiniteLength = math.MaxInt
+	// InfiniteDuration is the Duration of tracks which never end
+	InfiniteDuration time.Duration = math.MaxInt64
+)
+
+// Millis converts millis, as Lavalink reports them, to a time.Duration
+//
+// Millis is handy for the threshold passed to OnTrackStuck; a
+// TrackStuckHandler receives it as a time.Duration already.
+// InfiniteLength, and lengths too long for a time.Duration, are
+// InfiniteDuration.
+func Millis(ms int) time.Duration {
+	if ms == InfiniteLength || int64(ms) >= int64(InfiniteDuration/time.Millisecond) {
+		return InfiniteDuration
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// toMillis converts a time.Duration to millis, as Lavalink expects them
+func toMillis(d time.Duration) int {
+	return int(d / time.Millisecond)
+}
+
+// Duration returns the track's length, InfiniteDuration for streams
+func (info TrackInfo) Duration() time.Duration {
+	if info.Stream || info.Length == InfiniteLength {
+		return InfiniteDuration
+	}
+	return Millis(info.Length)
+}
+
+// PositionDuration returns the track's position as a time.Duration
+func (info TrackInfo) PositionDuration() time.Duration {
+	return Millis(info.Position)
+}
+
+// FormatLength formats the track's length like FormatDuration
+func (info TrackInfo) FormatLength() string {
+	return FormatDuration(info.Duration())
+}
+
+// FormatDuration formats a duration the way players show it, like
+// "3:45" or "1:02:03"; InfiniteDuration is formatted as "LIVE"
+func FormatDuration(d time.Duration) string {
+	if d == InfiniteDuration {
+		return "LIVE"
+	}
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		if d == math.MinInt64 {
+			// -d overflows, and is a nanosecond past math.MaxInt64
+			d = math.MaxInt64
+		} else {
+			d = -d
+		}
+	}
+
+	seconds := int64(d / time.Second)
+	h, m, s := seconds/3600, seconds/60%60, seconds%60
+	if h > 0 {
+		return fmt.Sprintf("%s%d:%02d:%02d", sign, h, m, s)
+	}
+	return fmt.Sprintf("%s%d:%02d", sign, m, s)
+}
+
+// Elapsed returns how far the player is into its track, interpolated
+// from Lavalink's last update
+func (player *Player) Elapsed() time.Duration {
+	return Millis(player.interpolatedPosition())
+}
+
+// PlayRange plays the given track from start until end
+//
+// Like PlayAtContext, a zero time is omitted.
+func (player *Player) PlayRange(ctx context.Context, track string, start time.Duration, end time.Duration) error {
+	return player.PlayAtContext(ctx, track, toMillis(start), toMillis(end))
+}
+
+// SeekTo seeks the player to the given position
+func (player *Player) SeekTo(ctx context.Context, position time.Duration) error {
+	return player.SeekContext(ctx, toMillis(position))
+}
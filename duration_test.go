@@ -0,0 +1,82 @@
+package gavalink_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/foxbot/gavalink"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                         "0:00",
+		225 * time.Second:         "3:45",
+		3723*time.Second + 999:    "1:02:03",
+		-5 * time.Second:          "-0:05",
+		gavalink.InfiniteDuration: "LIVE",
+		math.MinInt64:             "-2562047:47:16",
+	}
+	for d, want := range tests {
+		if got := gavalink.FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%d) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestMillis(t *testing.T) {
+	if d := gavalink.Millis(215000); d != 215*time.Second {
+		t.Errorf("Millis(215000) = %v", d)
+	}
+	if d := gavalink.Millis(gavalink.InfiniteLength); d != gavalink.InfiniteDuration {
+		t.Errorf("Millis(InfiniteLength) = %v, want InfiniteDuration", d)
+	}
+}
+
+func TestStreamDuration(t *testing.T) {
+	data := "QAAAkAIALGxvZmkgaGlwIGhvcCByYWRpbyAtIGJlYXRzIHRvIHJlbGF4L3N0dWR5IHRvAApDaGlsbGVkQ293f/////////8AC2hIVzFvWTI2a3hRAQEAK2h0dHBzOi8vd3d3LnlvdXR1YmUuY29tL3dhdGNoP3Y9aEhXMW9ZMjZreFEAB3lvdXR1YmUAAAAAAAAAAA=="
+	track, err := gavalink.DecodeString(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if track.Length != gavalink.InfiniteLength || track.Duration() != gavalink.InfiniteDuration {
+		t.Errorf("stream has length %d and duration %v", track.Length, track.Duration())
+	}
+	if s := track.FormatLength(); s != "LIVE" {
+		t.Errorf("stream length formatted as %q", s)
+	}
+
+	info := gavalink.TrackInfo{Length: 215000, Position: 60000}
+	if info.Duration() != 215*time.Second || info.FormatLength() != "3:35" {
+		t.Errorf("track has duration %v, formatted as %q", info.Duration(), info.FormatLength())
+	}
+	if info.PositionDuration() != time.Minute {
+		t.Errorf("track has position %v", info.PositionDuration())
+	}
+}
+
+type stuckHandler struct {
+	gavalink.DummyEventHandler
+	thresholds chan time.Duration
+}
+
+func (h stuckHandler) OnTrackStuckDuration(player *gavalink.Player, track string, threshold time.Duration) error {
+	h.thresholds <- threshold
+	return nil
+}
+
+func TestTrackStuckDuration(t *testing.T) {
+	srv := newTestServer(t)
+	handler := stuckHandler{thresholds: make(chan time.Duration, 1)}
+	newTestPlayer(t, srv, handler)
+
+	srv.Send(t, `{"op":"event","type":"TrackStuckEvent","guildId":"1","track":"track","thresholdMs":10000}`)
+	select {
+	case threshold := <-handler.thresholds:
+		if threshold != 10*time.Second {
+			t.Errorf("got threshold %v, want 10s", threshold)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnTrackStuckDuration wasn't called")
+	}
+}
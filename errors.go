@@ -0,0 +1,128 @@
+package gavalink
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrNoNodes is returned when a manager has no node to use
+	ErrNoNodes = errors.New("No nodes present")
+	// ErrNodeNotFound is returned when a node isn't part of a manager
+	ErrNodeNotFound = errors.New("Couldn't find that node")
+	// ErrDuplicateNode is returned when adding a node whose name is taken
+	ErrDuplicateNode = errors.New("A node with that name already exists")
+	// ErrPlayerNotFound is returned when a guild has no player
+	ErrPlayerNotFound = errors.New("Couldn't find a player for that guild")
+	// ErrVolumeOutOfRange is returned when setting an invalid volume
+	ErrVolumeOutOfRange = errors.New("Volume is out of range, must be within [0, 1000]")
+	// ErrInvalidVersion is returned when a node runs Lavalink < 3
+	ErrInvalidVersion = errors.New("This library requires Lavalink >= 3")
+	// ErrUnknownPayload is returned when a node sends a payload gavalink
+	// can't handle
+	ErrUnknownPayload = errors.New("Lavalink sent an unknown payload")
+	// ErrNilHandler is returned when joining without an event handler
+	ErrNilHandler = errors.New("You must provide an event handler. Use gavalink.DummyEventHandler if you wish to ignore events")
+	// ErrNoGateway is returned when joining or leaving without a gateway
+	ErrNoGateway = errors.New("No voice gateway set, use Lavalink.SetGateway")
+	// ErrClosed is returned when using a manager or node after Close
+	ErrClosed = errors.New("The Lavalink manager has been closed")
+	// ErrNoSession is returned when calling a session route on a node
+	// which hasn't sent a session ID, which requires Lavalink v3.7
+	ErrNoSession = errors.New("The node has no session, this requires Lavalink >= 3.7")
+	// ErrInvalidOption is matched by errors New returns for invalid
+	// options
+	ErrInvalidOption = errors.New("Invalid option")
+	// ErrQueueEmpty is returned when playing the next track of an empty
+	// queue
+	ErrQueueEmpty = errors.New("The queue is empty")
+	// ErrNoHistory is returned when playing the previous track of a
+	// player which played none
+	ErrNoHistory = errors.New("There is no previous track")
+	// ErrNoTrack is returned when seeking a player which isn't playing
+	// a track
+	ErrNoTrack = errors.New("The player isn't playing a track")
+	// ErrAlreadyPlaying is returned when playing a track with NoReplace
+	// while the player is playing another
+	ErrAlreadyPlaying = errors.New("The player is already playing a track")
+	// ErrNotSeekable is matched by a *PositionError for a track which
+	// can't be seeked, like a stream
+	ErrNotSeekable = errors.New("The track isn't seekable")
+	// ErrPositionOutOfRange is matched by a *PositionError for a
+	// position outside of its track
+	ErrPositionOutOfRange = errors.New("Position is out of range")
+	// ErrLoadFailed is matched by every *LoadFailedError
+	ErrLoadFailed = errors.New("Lavalink failed to load the tracks")
+)
+
+// NodeError is an error which occurred communicating with a node
+type NodeError struct {
+	// Node is the name of the node
+	Node string
+	Err  error
+}
+
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("node %s: %v", e.Node, e.Err)
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is returned when a Lavaplayer track can't be decoded
+type DecodeError struct {
+	// Offset is the byte offset in the decoded track at which decoding
+	// failed
+	Offset int64
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decoding track at byte %d: %v", e.Offset, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// PositionError is returned when playing or seeking a track at a
+// position it doesn't support
+type PositionError struct {
+	// Position is the position asked for
+	Position time.Duration
+	// Length is the track's length, InfiniteDuration for streams
+	Length time.Duration
+	// Err is ErrNotSeekable or ErrPositionOutOfRange
+	Err error
+}
+
+func (e *PositionError) Error() string {
+	return fmt.Sprintf("position %s of %s: %v", FormatDuration(e.Position), FormatDuration(e.Length), e.Err)
+}
+
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
+// LoadFailedError is returned when Lavalink answers a query with
+// LOAD_FAILED
+type LoadFailedError struct {
+	// Query is the query which failed to load
+	Query string
+	// Exception is the exception Lavaplayer ran into, if Lavalink sent one
+	Exception *Exception
+}
+
+func (e *LoadFailedError) Error() string {
+	if e.Exception == nil {
+		return fmt.Sprintf("loading %q failed", e.Query)
+	}
+	return fmt.Sprintf("loading %q failed (%s): %s", e.Query, e.Exception.Severity, e.Exception.Message)
+}
+
+// Is reports whether target is ErrLoadFailed
+func (e *LoadFailedError) Is(target error) bool {
+	return target == ErrLoadFailed
+}
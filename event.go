@@ -1,5 +1,27 @@
 package gavalink
 
+import "time"
+
+// Reasons a track ends with, as passed to EventHandler.OnTrackEnd
+const (
+	// EndReasonFinished means the track played to its end
+	EndReasonFinished = "FINISHED"
+	// EndReasonLoadFailed means the track failed to start
+	EndReasonLoadFailed = "LOAD_FAILED"
+	// EndReasonStopped means the track was stopped
+	EndReasonStopped = "STOPPED"
+	// EndReasonReplaced means another track was played instead
+	EndReasonReplaced = "REPLACED"
+	// EndReasonCleanup means the player was cleaned up
+	EndReasonCleanup = "CLEANUP"
+)
+
+// MayStartNext reports whether a track which ended with reason should
+// be followed by the next one
+func MayStartNext(reason string) bool {
+	return reason == EndReasonFinished || reason == EndReasonLoadFailed
+}
+
 // EventHandler defines events that Lavalink may send to a player
 type EventHandler interface {
 	OnTrackEnd(player *Player, track string, reason string) error
@@ -7,6 +29,15 @@ type EventHandler interface {
 	OnTrackStuck(player *Player, track string, threshold int) error
 }
 
+// TrackStuckHandler receives the threshold of stuck tracks as a
+// time.Duration
+//
+// An EventHandler which also implements TrackStuckHandler gets
+// OnTrackStuckDuration instead of OnTrackStuck.
+type TrackStuckHandler interface {
+	OnTrackStuckDuration(player *Player, track string, threshold time.Duration) error
+}
+
 // DummyEventHandler provides an empty event handler for users who
 // wish to drop events outright. This is not recommended.
 type DummyEventHandler struct{}
@@ -25,3 +56,63 @@ func (d DummyEventHandler) OnTrackException(player *Player, track string, reason
 func (d DummyEventHandler) OnTrackStuck(player *Player, track string, threshold int) error {
 	return nil
 }
+
+// NodeEventType is the kind of a NodeEvent
+type NodeEventType int
+
+const (
+	// NodeConnected is sent when a node added to the manager connects
+	NodeConnected NodeEventType = iota
+	// NodeDisconnected is sent when a node's websocket closes
+	NodeDisconnected
+	// NodeReconnected is sent when a node reconnects after a disconnect
+	NodeReconnected
+	// NodeVersionMismatch is sent when a node runs an unsupported
+	// Lavalink version
+	NodeVersionMismatch
+	// NodeRemoved is sent when a node is removed from the manager
+	NodeRemoved
+)
+
+func (t NodeEventType) String() string {
+	switch t {
+	case NodeConnected:
+		return "connected"
+	case NodeDisconnected:
+		return "disconnected"
+	case NodeReconnected:
+		return "reconnected"
+	case NodeVersionMismatch:
+		return "version mismatch"
+	case NodeRemoved:
+		return "removed"
+	}
+	return "unknown"
+}
+
+// NodeEvent describes a change in a node's connection
+type NodeEvent struct {
+	Type NodeEventType
+	// Node is the name of the node
+	Node string
+	// Err is the error which caused the event, if any
+	Err error
+	// CloseCode is the websocket close code of a NodeDisconnected event,
+	// or 0 if the connection closed without one
+	CloseCode int
+	// Attempt is the reconnect attempt of a NodeReconnected event
+	Attempt int
+}
+
+// NodeEventHandler receives lifecycle events of a manager's nodes
+type NodeEventHandler interface {
+	OnNodeEvent(event NodeEvent)
+}
+
+// NodeEventHandlerFunc adapts a function to a NodeEventHandler
+type NodeEventHandlerFunc func(event NodeEvent)
+
+// OnNodeEvent calls f(event)
+func (f NodeEventHandlerFunc) OnNodeEvent(event NodeEvent) {
+	f(event)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"os"
@@ -8,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/foxbot/gavalink"
@@ -37,7 +39,6 @@ func main() {
 
 	dg.AddHandler(ready)
 	dg.AddHandler(messageCreate)
-	dg.AddHandler(voiceServerUpdate)
 
 	err = dg.Open()
 	if err != nil {
@@ -48,16 +49,29 @@ func main() {
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, os.Kill)
 	<-sc
 
+	if lavalink != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = lavalink.Close(ctx, true)
+		cancel()
+		if err != nil {
+			log.Println(err)
+		}
+	}
 	dg.Close()
 }
 
 func ready(s *discordgo.Session, event *discordgo.Ready) {
 	log.Println("discordgo ready!")
-	s.UpdateStatus(0, "gavalink")
+	s.UpdateGameStatus(0, "gavalink")
 
-	lavalink = gavalink.NewLavalink("1", event.User.ID)
+	var err error
+	lavalink, err = gavalink.New(event.User.ID, gavalink.WithShardCount(1))
+	if err != nil {
+		log.Println(err)
+		return
+	}
 
-	err := lavalink.AddNodes(gavalink.NodeConfig{
+	err = lavalink.AddNodes(gavalink.NodeConfig{
 		REST:      "http://localhost:2333",
 		WebSocket: "ws://localhost:2334",
 		Password:  "youshallnotpass",
@@ -65,6 +79,8 @@ func ready(s *discordgo.Session, event *discordgo.Ready) {
 	if err != nil {
 		log.Println(err)
 	}
+
+	lavalink.SetGateway(gateway{s})
 }
 
 func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
@@ -88,7 +104,9 @@ func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 		for _, vs := range g.VoiceStates {
 			if vs.UserID == m.Author.ID {
 				log.Println("trying to connect to channel")
-				err = s.ChannelVoiceJoinManual(c.GuildID, vs.ChannelID, false, false)
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				player, err = lavalink.Join(ctx, c.GuildID, vs.ChannelID, new(gavalink.DummyEventHandler))
+				cancel()
 				if err != nil {
 					log.Println(err)
 				} else {
@@ -121,6 +139,11 @@ func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 		if err != nil {
 			log.Println(err)
 		}
+	} else if m.Content == "~>>leave" {
+		err := lavalink.Leave(player.GuildID())
+		if err != nil {
+			log.Println(err)
+		}
 	} else if m.Content == "~>>pause" {
 		err := player.Pause(!player.Paused())
 		if err != nil {
@@ -140,32 +163,30 @@ func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 	}
 }
 
-func voiceServerUpdate(s *discordgo.Session, event *discordgo.VoiceServerUpdate) {
-	log.Println("received VSU")
-	vsu := gavalink.VoiceServerUpdate{
-		Endpoint: event.Endpoint,
-		GuildID:  event.GuildID,
-		Token:    event.Token,
-	}
-
-	if p, err := lavalink.GetPlayer(event.GuildID); err == nil {
-		err = p.Forward(s.State.SessionID, vsu)
-		if err != nil {
-			log.Println(err)
-		}
-		return
-	}
+// gateway adapts a discordgo session to a gavalink.VoiceGateway
+type gateway struct {
+	s *discordgo.Session
+}
 
-	node, err := lavalink.BestNode()
-	if err != nil {
-		log.Println(err)
-		return
-	}
+// UpdateVoiceState sends an empty channelID as null, which disconnects
+func (g gateway) UpdateVoiceState(guildID string, channelID string, selfMute bool, selfDeaf bool) error {
+	return g.s.ChannelVoiceJoinManual(guildID, channelID, selfMute, selfDeaf)
+}
 
-	handler := new(gavalink.DummyEventHandler)
-	player, err = node.CreatePlayer(event.GuildID, s.State.SessionID, vsu, handler)
-	if err != nil {
-		log.Println(err)
-		return
-	}
+func (g gateway) HandleVoiceEvents(onState func(gavalink.VoiceState), onServer func(gavalink.VoiceServerUpdate)) {
+	g.s.AddHandler(func(s *discordgo.Session, event *discordgo.VoiceStateUpdate) {
+		onState(gavalink.VoiceState{
+			GuildID:   event.GuildID,
+			ChannelID: event.ChannelID,
+			UserID:    event.UserID,
+			SessionID: event.SessionID,
+		})
+	})
+	g.s.AddHandler(func(s *discordgo.Session, event *discordgo.VoiceServerUpdate) {
+		onServer(gavalink.VoiceServerUpdate{
+			GuildID:  event.GuildID,
+			Endpoint: event.Endpoint,
+			Token:    event.Token,
+		})
+	})
 }
@@ -0,0 +1,172 @@
+package gavalink
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Extension adds support for a Lavalink plugin to a manager
+//
+// Plugins may add their own websocket ops, player events and REST
+// routes; an extension registers handlers and routes for them.
+type Extension interface {
+	// Register registers the extension's handlers and routes
+	Register(registry *Registry) error
+}
+
+// OpHandler handles a websocket op sent by a node
+//
+// payload is the whole raw message, including the op field.
+type OpHandler func(node *Node, payload json.RawMessage) error
+
+// PlayerEventHandler handles a player event sent by a node
+//
+// payload is the whole raw event, including the op and type fields.
+type PlayerEventHandler func(player *Player, payload json.RawMessage) error
+
+// RawHandler receives payloads neither gavalink nor any extension
+// handles
+//
+// These include binary and invalid JSON frames, and events for guilds
+// without a player.
+type RawHandler func(node *Node, payload []byte)
+
+// Route is a REST route of a Lavalink plugin
+type Route struct {
+	// Name identifies the route, e.g. to the manager's Observer
+	Name   string
+	Method string
+	// Path is the route's path, which may contain `{name}` placeholders
+	// filled in by Node.Call, e.g. `/v4/sessions/{sessionId}/players`
+	Path string
+}
+
+// Registry holds the handlers and routes extensions register
+type Registry struct {
+	mu     sync.RWMutex
+	ops    map[string]OpHandler
+	events map[string]PlayerEventHandler
+	routes map[string]Route
+	raw    RawHandler
+}
+
+func newRegistry() *Registry {
+	return &Registry{
+		ops:    make(map[string]OpHandler),
+		events: make(map[string]PlayerEventHandler),
+		routes: make(map[string]Route),
+	}
+}
+
+// HandleOp registers a handler for a websocket op
+//
+// Ops gavalink handles itself can't be overridden.
+func (r *Registry) HandleOp(op string, handler OpHandler) {
+	r.mu.Lock()
+	r.ops[op] = handler
+	r.mu.Unlock()
+}
+
+// HandleEvent registers a handler for a player event type
+//
+// Events gavalink handles itself can't be overridden.
+func (r *Registry) HandleEvent(eventType string, handler PlayerEventHandler) {
+	r.mu.Lock()
+	r.events[eventType] = handler
+	r.mu.Unlock()
+}
+
+// AddRoute registers a REST route
+func (r *Registry) AddRoute(route Route) {
+	r.mu.Lock()
+	r.routes[route.Name] = route
+	r.mu.Unlock()
+}
+
+// Route gets a registered REST route by its name
+func (r *Registry) Route(name string) (Route, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	route, ok := r.routes[name]
+	return route, ok
+}
+
+// HandleRaw sets the handler receiving unhandled payloads
+//
+// Without a raw handler, unhandled payloads are logged at debug level
+// and dropped.
+func (r *Registry) HandleRaw(handler RawHandler) {
+	r.mu.Lock()
+	r.raw = handler
+	r.mu.Unlock()
+}
+
+func (r *Registry) op(op string) OpHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.ops[op]
+}
+
+func (r *Registry) event(eventType string) PlayerEventHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.events[eventType]
+}
+
+func (r *Registry) rawHandler() RawHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.raw
+}
+
+// HandleOp registers a handler for a websocket op, decoding its payload
+// into a T
+func HandleOp[T any](registry *Registry, op string, handler func(node *Node, payload T) error) {
+	registry.HandleOp(op, func(node *Node, raw json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return err
+		}
+		return handler(node, payload)
+	})
+}
+
+// HandleEvent registers a handler for a player event type, decoding the
+// event into a T
+func HandleEvent[T any](registry *Registry, eventType string, handler func(player *Player, event T) error) {
+	registry.HandleEvent(eventType, func(player *Player, raw json.RawMessage) error {
+		var event T
+		if err := json.Unmarshal(raw, &event); err != nil {
+			return err
+		}
+		return handler(player, event)
+	})
+}
+
+// Registry returns the manager's registry of extension handlers
+func (lavalink *Lavalink) Registry() *Registry {
+	return lavalink.registry
+}
+
+// Use registers an extension with the manager
+func (lavalink *Lavalink) Use(extension Extension) error {
+	return extension.Register(lavalink.registry)
+}
+
+// Call sends a request to a plugin's REST route
+//
+// Placeholders in the route's path are replaced by the escaped values of
+// vars. body and out are encoded and decoded as JSON, if they aren't nil.
+func (node *Node) Call(ctx context.Context, route Route, vars map[string]string, body interface{}, out interface{}) (err error) {
+	ctx, span := node.manager.tracer().Start(ctx, "gavalink.Call", Attribute{AttrNode, node.config.Name})
+	defer func() { span.End(err) }()
+
+	path := route.Path
+	for k, v := range vars {
+		path = strings.ReplaceAll(path, "{"+k+"}", url.PathEscape(v))
+	}
+	return node.rest(ctx, route.Name, route.Method, path, body, out)
+}
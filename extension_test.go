@@ -0,0 +1,90 @@
+package gavalink_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foxbot/gavalink"
+)
+
+type customOp struct {
+	Value int `json:"value"`
+}
+
+type customEvent struct {
+	GuildID string `json:"guildId"`
+}
+
+type customExtension struct {
+	values chan int
+	events chan string
+}
+
+func (e customExtension) Register(registry *gavalink.Registry) error {
+	gavalink.HandleOp(registry, "custom", func(node *gavalink.Node, payload customOp) error {
+		e.values <- payload.Value
+		return nil
+	})
+	gavalink.HandleEvent(registry, "CustomEvent", func(player *gavalink.Player, event customEvent) error {
+		e.events <- player.GuildID()
+		return nil
+	})
+	return nil
+}
+
+func TestExtension(t *testing.T) {
+	srv := newTestServer(t)
+	lavalink, _ := newTestPlayer(t, srv, gavalink.DummyEventHandler{})
+
+	ext := customExtension{make(chan int, 1), make(chan string, 1)}
+	if err := lavalink.Use(ext); err != nil {
+		t.Fatal(err)
+	}
+	raw := make(chan string, 1)
+	lavalink.Registry().HandleRaw(func(node *gavalink.Node, payload []byte) {
+		raw <- string(payload)
+	})
+
+	srv.Send(t, `{"op":"custom","value":42}`)
+	select {
+	case v := <-ext.values:
+		if v != 42 {
+			t.Errorf("got value %d, want 42", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("custom op wasn't handled")
+	}
+
+	srv.Send(t, `{"op":"event","type":"CustomEvent","guildId":"1"}`)
+	select {
+	case guildID := <-ext.events:
+		if guildID != "1" {
+			t.Errorf("got event for guild %s, want 1", guildID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("plugin event wasn't handled")
+	}
+
+	tests := []struct {
+		name   string
+		send   func()
+		packet string
+	}{
+		{"unknown op", func() { srv.Send(t, `{"op":"unknown"}`) }, `{"op":"unknown"}`},
+		{"unknown event", func() { srv.Send(t, `{"op":"event","type":"Unknown","guildId":"1"}`) }, `{"op":"event","type":"Unknown","guildId":"1"}`},
+		{"event without player", func() { srv.Send(t, `{"op":"event","type":"CustomEvent","guildId":"2"}`) }, `{"op":"event","type":"CustomEvent","guildId":"2"}`},
+		{"invalid JSON", func() { srv.Send(t, `not json`) }, `not json`},
+		{"binary frame", func() { srv.SendBinary(t, []byte{0, 1, 2}) }, "\x00\x01\x02"},
+	}
+	for _, tt := range tests {
+		tt.send()
+		select {
+		case payload := <-raw:
+			if payload != tt.packet {
+				t.Errorf("%s: got raw payload %q, want %q", tt.name, payload, tt.packet)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s wasn't passed to the raw handler", tt.name)
+		}
+	}
+}
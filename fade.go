@@ -0,0 +1,360 @@
+package gavalink
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// FadeCurve maps the progress of a fade, within [0, 1], to the progress
+// of the volume, within [0, 1]
+type FadeCurve func(progress float64) float64
+
+var (
+	// Linear changes the volume at a constant rate
+	Linear FadeCurve = func(progress float64) float64 {
+		return progress
+	}
+	// EaseIn changes the volume slowly at first, then faster
+	EaseIn FadeCurve = func(progress float64) float64 {
+		return progress * progress
+	}
+	// EaseOut changes the volume quickly at first, then slower
+	EaseOut FadeCurve = func(progress float64) float64 {
+		return 1 - (1-progress)*(1-progress)
+	}
+	// EaseInOut changes the volume slowly at both ends of the fade
+	EaseInOut FadeCurve = func(progress float64) float64 {
+		return progress * progress * (3 - 2*progress)
+	}
+)
+
+// DefaultFadeStep is how often a fade updates the volume by default
+const DefaultFadeStep = 100 * time.Millisecond
+
+// FadeOptions configures how a player fades its volume
+type FadeOptions struct {
+	// Curve shapes fades, Linear if nil
+	Curve FadeCurve
+	// Step is how often fades update the volume, DefaultFadeStep if 0
+	Step time.Duration
+	// Filters fades through the volume filter instead of volume ops
+	//
+	// The volume filter is applied to the audio directly, so fades
+	// through it take effect sooner. Filters require Lavalink >= 3.4.
+	Filters bool
+
+	// In fades tracks in over this duration when they start playing
+	In time.Duration
+	// Out fades tracks out over this duration before they are stopped
+	Out time.Duration
+	// Transition fades out the last part of a track, and plays the next
+	// track of the queue once the fade is done
+	//
+	// The end of the track is detected using the interpolated position,
+	// so streams never transition.
+	Transition time.Duration
+}
+
+// SetFadeOptions sets how the player fades its volume
+//
+// Options apply to tracks played from now on.
+func (player *Player) SetFadeOptions(options FadeOptions) {
+	player.mu.Lock()
+	player.fadeOptions = options
+	player.mu.Unlock()
+}
+
+// FadeOptions returns how the player fades its volume
+func (player *Player) FadeOptions() FadeOptions {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	return player.fadeOptions
+}
+
+// FadeVolume gradually changes the player's volume to target over
+// duration, and blocks until it's done or ctx is done
+//
+// Starting another fade cancels the one running.
+func (player *Player) FadeVolume(ctx context.Context, target int, duration time.Duration) error {
+	if target < 0 || target > 1000 {
+		return ErrVolumeOutOfRange
+	}
+
+	ctx, cancel := player.startFade(ctx)
+	defer cancel()
+	return player.fade(ctx, target, duration, true)
+}
+
+// startFade cancels the running fade, and returns the context of a new
+// one
+func (player *Player) startFade(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	player.mu.Lock()
+	if player.fadeCancel != nil {
+		player.fadeCancel()
+	}
+	player.fadeCancel = cancel
+	player.mu.Unlock()
+
+	return ctx, cancel
+}
+
+// interrupt cancels the running fade, and the transition of the current
+// track
+func (player *Player) interrupt() {
+	player.mu.Lock()
+	player.plays++
+	if player.fadeCancel != nil {
+		player.fadeCancel()
+		player.fadeCancel = nil
+	}
+	player.mu.Unlock()
+}
+
+// fade ramps the volume to target; if commit is set, the player's volume
+// is set to target once done, otherwise the volume is only played at
+func (player *Player) fade(ctx context.Context, target int, duration time.Duration, commit bool) error {
+	options := player.FadeOptions()
+	curve := options.Curve
+	if curve == nil {
+		curve = Linear
+	}
+	step := options.Step
+	if step <= 0 {
+		step = DefaultFadeStep
+	}
+
+	from := player.level()
+	start := time.Now()
+	ticker := time.NewTicker(step)
+	defer ticker.Stop()
+
+	for {
+		progress := 1.0
+		if elapsed := time.Since(start); elapsed < duration {
+			progress = float64(elapsed) / float64(duration)
+		}
+		volume := from + int(math.Round(float64(target-from)*curve(progress)))
+
+		if progress >= 1 && commit {
+			return player.commitVolume(ctx, volume)
+		}
+		if volume != player.level() {
+			if err := player.sendVolume(ctx, volume); err != nil {
+				return err
+			}
+		}
+		if progress >= 1 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// volume returns the player's volume, through the volume filter if the
+// player fades through it
+func (player *Player) volume() int {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	if !player.fadeOptions.Filters {
+		return player.vol
+	}
+	if player.filters == nil || player.filters.Volume == nil {
+		return 100
+	}
+	return int(math.Round(float64(*player.filters.Volume) * 100))
+}
+
+// level returns the volume the player currently plays at, which
+// differs from its volume while fading
+func (player *Player) level() int {
+	player.mu.Lock()
+	faded, level := player.faded, player.fadeLevel
+	player.mu.Unlock()
+	if faded {
+		return level
+	}
+	return player.volume()
+}
+
+// sendVolume plays at a volume, without changing the player's volume
+func (player *Player) sendVolume(ctx context.Context, volume int) error {
+	var err error
+	if player.FadeOptions().Filters {
+		err = player.write(ctx, opFilters, filtersMessage{
+			Op:      opFilters,
+			GuildID: player.guildID,
+			Filters: player.filtersWithVolume(volume),
+		})
+	} else {
+		err = player.send(ctx, message{
+			Op:      opVolume,
+			GuildID: player.guildID,
+			Volume:  &volume,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	player.mu.Lock()
+	player.faded = true
+	player.fadeLevel = volume
+	player.mu.Unlock()
+	return nil
+}
+
+// commitVolume sets the player's volume
+func (player *Player) commitVolume(ctx context.Context, volume int) error {
+	var err error
+	if player.FadeOptions().Filters {
+		err = player.SetFilters(ctx, player.filtersWithVolume(volume))
+	} else {
+		err = player.VolumeContext(ctx, volume)
+	}
+	if err != nil {
+		return err
+	}
+	player.setFaded(false)
+	return nil
+}
+
+// restoreVolume plays at the player's volume again after a fade
+func (player *Player) restoreVolume(ctx context.Context) error {
+	player.mu.Lock()
+	faded := player.faded
+	player.mu.Unlock()
+	if !faded {
+		return nil
+	}
+	if err := player.sendVolume(ctx, player.volume()); err != nil {
+		return err
+	}
+	player.setFaded(false)
+	return nil
+}
+
+// setFaded sets whether the player plays at another volume than its own
+func (player *Player) setFaded(faded bool) {
+	player.mu.Lock()
+	player.faded = faded
+	player.mu.Unlock()
+}
+
+func (player *Player) filtersWithVolume(volume int) *Filters {
+	filters := new(Filters)
+	if current := player.Filters(); current != nil {
+		*filters = *current
+	}
+	v := float32(volume) / 100
+	filters.Volume = &v
+	return filters
+}
+
+// fadeIn fades a track in, in the background
+func (player *Player) fadeIn(duration time.Duration) {
+	player.goFade(func(ctx context.Context) {
+		if err := player.fade(ctx, player.volume(), duration, false); err != nil {
+			if ctx.Err() == nil {
+				player.logger().Warn("couldn't fade in track", "err", err)
+			}
+			return
+		}
+		player.setFaded(false)
+	})
+}
+
+// watchTransition fades out the end of a track and plays the next
+// track of the queue, in the background
+func (player *Player) watchTransition(track string, play uint64, transition time.Duration) {
+	info, err := player.manager.DecodeTrack(track)
+	if err != nil {
+		player.logger().Debug("not transitioning undecodable track", "err", err)
+		return
+	}
+	length := info.Duration()
+	if length == InfiniteDuration {
+		return
+	}
+
+	manager := player.manager
+	if manager.ctx.Err() != nil {
+		return
+	}
+	manager.wg.Add(1)
+	go func() {
+		defer manager.wg.Done()
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+		for {
+			select {
+			case <-manager.ctx.Done():
+				return
+			case <-timer.C:
+			}
+			if !player.playing(play, track) {
+				return
+			}
+
+			position := time.Duration(player.interpolatedPosition()) * time.Millisecond
+			remaining := length - position
+			if remaining <= transition && !player.Paused() {
+				break
+			}
+			// seeks move the end, check again at least every second
+			wait := remaining - transition
+			if wait <= 0 || wait > time.Second {
+				wait = time.Second
+			}
+			timer.Reset(wait)
+		}
+
+		// the track may have been stopped or replaced since
+		if player.queue.Len() == 0 || !player.playing(play, track) {
+			return
+		}
+
+		remaining := length - time.Duration(player.interpolatedPosition())*time.Millisecond
+		ctx, cancel := player.startFade(manager.ctx)
+		defer cancel()
+		if err := player.fade(ctx, 0, remaining, false); err != nil {
+			return
+		}
+		if !player.playing(play, track) {
+			return
+		}
+
+		if err := player.PlayNext(manager.ctx); err != nil {
+			player.logger().Warn("couldn't transition to next track", "err", err)
+			return
+		}
+		if player.FadeOptions().In <= 0 {
+			if err := player.restoreVolume(manager.ctx); err != nil {
+				player.logger().Warn("couldn't restore volume after transition", "err", err)
+			}
+		}
+	}()
+}
+
+// goFade runs a fade in the background, until it's done, replaced by
+// another fade or the manager is closed
+func (player *Player) goFade(fade func(ctx context.Context)) {
+	manager := player.manager
+	if manager.ctx.Err() != nil {
+		return
+	}
+	ctx, cancel := player.startFade(manager.ctx)
+	manager.wg.Add(1)
+	go func() {
+		defer manager.wg.Done()
+		defer cancel()
+		fade(ctx)
+	}()
+}
@@ -0,0 +1,131 @@
+package gavalink
+
+import (
+	"context"
+	"encoding/json"
+)
+
+const opFilters = "filters"
+
+// Filters are the audio filters Lavalink applies to a player, see
+// https://lavalink.dev/api/rest#filters
+//
+// Filters left nil are disabled. Setting filters replaces all filters
+// the player had before.
+type Filters struct {
+	// Volume is the player's volume as a factor, 1.0 being 100%
+	//
+	// Unlike Player.Volume, Volume may be set above 5.0.
+	Volume     *float32          `json:"volume,omitempty"`
+	Equalizer  []EqualizerBand   `json:"equalizer,omitempty"`
+	Karaoke    *KaraokeFilter    `json:"karaoke,omitempty"`
+	Timescale  *TimescaleFilter  `json:"timescale,omitempty"`
+	Tremolo    *TremoloFilter    `json:"tremolo,omitempty"`
+	Vibrato    *VibratoFilter    `json:"vibrato,omitempty"`
+	Rotation   *RotationFilter   `json:"rotation,omitempty"`
+	Distortion *DistortionFilter `json:"distortion,omitempty"`
+	ChannelMix *ChannelMixFilter `json:"channelMix,omitempty"`
+	LowPass    *LowPassFilter    `json:"lowPass,omitempty"`
+	// PluginFilters configures filters added by Lavalink plugins, by
+	// plugin name
+	PluginFilters map[string]json.RawMessage `json:"pluginFilters,omitempty"`
+}
+
+// EqualizerBand sets the gain of one of the 15 equalizer bands
+type EqualizerBand struct {
+	// Band is the band, within [0, 14]
+	Band int `json:"band"`
+	// Gain is the band's gain, within [-0.25, 1.0]; 0 leaves the band
+	// unchanged
+	Gain float32 `json:"gain"`
+}
+
+// KaraokeFilter eliminates part of a band, usually vocals
+type KaraokeFilter struct {
+	Level       float32 `json:"level"`
+	MonoLevel   float32 `json:"monoLevel"`
+	FilterBand  float32 `json:"filterBand"`
+	FilterWidth float32 `json:"filterWidth"`
+}
+
+// TimescaleFilter changes the speed, pitch and rate of the audio
+type TimescaleFilter struct {
+	Speed float32 `json:"speed"`
+	Pitch float32 `json:"pitch"`
+	Rate  float32 `json:"rate"`
+}
+
+// TremoloFilter oscillates the volume
+type TremoloFilter struct {
+	Frequency float32 `json:"frequency"`
+	Depth     float32 `json:"depth"`
+}
+
+// VibratoFilter oscillates the pitch
+type VibratoFilter struct {
+	Frequency float32 `json:"frequency"`
+	Depth     float32 `json:"depth"`
+}
+
+// RotationFilter rotates the audio around the stereo channels
+type RotationFilter struct {
+	RotationHz float32 `json:"rotationHz"`
+}
+
+// DistortionFilter distorts the audio
+type DistortionFilter struct {
+	SinOffset float32 `json:"sinOffset"`
+	SinScale  float32 `json:"sinScale"`
+	CosOffset float32 `json:"cosOffset"`
+	CosScale  float32 `json:"cosScale"`
+	TanOffset float32 `json:"tanOffset"`
+	TanScale  float32 `json:"tanScale"`
+	Offset    float32 `json:"offset"`
+	Scale     float32 `json:"scale"`
+}
+
+// ChannelMixFilter mixes the left and right channels
+type ChannelMixFilter struct {
+	LeftToLeft   float32 `json:"leftToLeft"`
+	LeftToRight  float32 `json:"leftToRight"`
+	RightToLeft  float32 `json:"rightToLeft"`
+	RightToRight float32 `json:"rightToRight"`
+}
+
+// LowPassFilter suppresses higher frequencies
+type LowPassFilter struct {
+	Smoothing float32 `json:"smoothing"`
+}
+
+// filtersMessage is a filters op, which carries the filters at its top
+// level
+type filtersMessage struct {
+	Op      string `json:"op"`
+	GuildID string `json:"guildId"`
+	*Filters
+}
+
+// SetFilters replaces the player's filters
+//
+// A nil filters disables all filters. Filters require Lavalink >= 3.4.
+func (player *Player) SetFilters(ctx context.Context, filters *Filters) error {
+	msg := filtersMessage{
+		Op:      opFilters,
+		GuildID: player.guildID,
+		Filters: filters,
+	}
+	if err := player.write(ctx, opFilters, msg); err != nil {
+		return err
+	}
+	player.mu.Lock()
+	player.filters = filters
+	player.mu.Unlock()
+	return nil
+}
+
+// Filters returns the player's filters, nil if none are set
+func (player *Player) Filters() *Filters {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	return player.filters
+}
@@ -1,8 +1,13 @@
 module github.com/foxbot/gavalink
 
-go 1.12
+go 1.21
 
 require (
-	github.com/bwmarrin/discordgo v0.19.0
-	github.com/gorilla/websocket v1.4.0
+	github.com/bwmarrin/discordgo v0.27.1
+	github.com/gorilla/websocket v1.4.2
+)
+
+require (
+	golang.org/x/crypto v0.0.0-20210421170649-83a5a9bb288b // indirect
+	golang.org/x/sys v0.17.0 // indirect
 )
@@ -0,0 +1,160 @@
+package gavalink
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultHistorySize is how many tracks a player's history holds by
+// default
+const DefaultHistorySize = 50
+
+// HistoryEntry is a track a player played
+type HistoryEntry struct {
+	// Track is the base64 encoded Lavaplayer track
+	Track string    `json:"track"`
+	Info  TrackInfo `json:"info"`
+	// Start is when the track started playing
+	Start time.Time `json:"start"`
+	// End is when the track ended, zero while it is playing
+	End time.Time `json:"end"`
+	// Reason is the reason the track ended with, see EndReasonFinished
+	Reason string `json:"reason,omitempty"`
+}
+
+// History holds the tracks a player played, oldest first
+//
+// Once full, the oldest tracks are dropped. A History is safe for
+// concurrent use.
+type History struct {
+	mu      sync.Mutex
+	size    int
+	entries []HistoryEntry
+}
+
+// Entries returns a copy of the history, oldest first
+func (history *History) Entries() []HistoryEntry {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	entries := make([]HistoryEntry, len(history.entries))
+	copy(entries, history.entries)
+	return entries
+}
+
+// Len returns the amount of entries in the history
+func (history *History) Len() int {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+	return len(history.entries)
+}
+
+// Contains reports whether a track with the given identifier was
+// played
+func (history *History) Contains(identifier string) bool {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	for _, e := range history.entries {
+		if e.Info.Identifier == identifier {
+			return true
+		}
+	}
+	return false
+}
+
+// Clear removes all entries from the history
+func (history *History) Clear() {
+	history.mu.Lock()
+	history.entries = nil
+	history.mu.Unlock()
+}
+
+// add appends entries, dropping the oldest ones beyond the history's size
+func (history *History) add(entries ...HistoryEntry) {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	history.entries = append(history.entries, entries...)
+	if over := len(history.entries) - history.size; over > 0 {
+		copy(history.entries, history.entries[over:])
+		history.entries = history.entries[:history.size]
+	}
+}
+
+// end marks the oldest entry of a playing track as ended
+//
+// Plays end in the order they started, so a track played again ends
+// its earlier play first.
+func (history *History) end(track string, reason string, at time.Time) {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	for i := range history.entries {
+		e := &history.entries[i]
+		if e.Track == track && e.End.IsZero() {
+			e.End = at
+			e.Reason = reason
+			return
+		}
+	}
+}
+
+// pop removes and returns the newest entry which isn't the track that's
+// playing, along with every entry after it
+func (history *History) pop(playing string) (HistoryEntry, bool) {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	for i := len(history.entries) - 1; i >= 0; i-- {
+		e := history.entries[i]
+		if e.Track == playing && e.End.IsZero() {
+			continue
+		}
+		history.entries = history.entries[:i]
+		return e, true
+	}
+	return HistoryEntry{}, false
+}
+
+// History returns the tracks the player played
+func (player *Player) History() *History {
+	return &player.history
+}
+
+// record adds a track starting to play to the player's history
+func (player *Player) record(track string) {
+	entry := HistoryEntry{
+		Track: track,
+		Start: time.Now(),
+	}
+	if info, err := player.manager.DecodeTrack(track); err == nil {
+		entry.Info = *info
+	} else {
+		player.logger().Debug("recording undecodable track", "err", err)
+	}
+	player.history.add(entry)
+}
+
+// Previous plays the track played before the current one again
+//
+// The current track is put back at the front of the queue. Previous
+// returns ErrNoHistory if no track was played before.
+func (player *Player) Previous(ctx context.Context) error {
+	current := player.Track()
+	entry, ok := player.history.pop(current)
+	if !ok {
+		return ErrNoHistory
+	}
+	if current != "" {
+		// the current track was recorded as playing, so is dropped too
+		info, _ := player.manager.DecodeTrack(current)
+		track := Track{Data: current}
+		if info != nil {
+			track.Info = *info
+		}
+		player.queue.Insert(0, track)
+	}
+	return player.PlayContext(ctx, entry.Track)
+}
@@ -0,0 +1,146 @@
+package gavalink
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Info describes the Lavalink server a node runs
+//
+// Lavalink v3 only reports its version; every other field is only set
+// by Lavalink v4 and above.
+type Info struct {
+	Version Version `json:"version"`
+	// BuildTime is when Lavalink was built, in millis since the epoch
+	BuildTime      int64        `json:"buildTime"`
+	Git            GitInfo      `json:"git"`
+	JVM            string       `json:"jvm"`
+	Lavaplayer     string       `json:"lavaplayer"`
+	SourceManagers []string     `json:"sourceManagers"`
+	Filters        []string     `json:"filters"`
+	Plugins        []PluginInfo `json:"plugins"`
+}
+
+// Version is a Lavalink semantic version
+type Version struct {
+	Semver     string `json:"semver"`
+	Major      int    `json:"major"`
+	Minor      int    `json:"minor"`
+	Patch      int    `json:"patch"`
+	PreRelease string `json:"preRelease"`
+	Build      string `json:"build"`
+}
+
+// GitInfo describes the commit Lavalink was built from
+type GitInfo struct {
+	Branch string `json:"branch"`
+	Commit string `json:"commit"`
+	// CommitTime is when the commit was made, in millis since the epoch
+	CommitTime int64 `json:"commitTime"`
+}
+
+// PluginInfo describes a plugin loaded by Lavalink
+type PluginInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Built returns when Lavalink was built
+func (info *Info) Built() time.Time {
+	return time.Unix(0, info.BuildTime*int64(time.Millisecond))
+}
+
+// HasSourceManager returns whether Lavalink has the named source manager
+// enabled, e.g. `youtube`
+func (info *Info) HasSourceManager(name string) bool {
+	return contains(info.SourceManagers, name)
+}
+
+// HasFilter returns whether Lavalink supports the named filter, e.g.
+// `timescale`
+func (info *Info) HasFilter(name string) bool {
+	return contains(info.Filters, name)
+}
+
+// Plugin returns the loaded plugin with the given name, if any
+func (info *Info) Plugin(name string) (PluginInfo, bool) {
+	for _, p := range info.Plugins {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return PluginInfo{}, false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// APIVersion returns the Lavalink API version the node reported when it
+// last connected
+func (node *Node) APIVersion() int {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	return node.apiVersion
+}
+
+// SessionID returns the session ID the node sent when it last connected
+//
+// Only Lavalink v3.7 and above send a session ID.
+func (node *Node) SessionID() string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	return node.sessionID
+}
+
+// Info gets information about the Lavalink server the node runs
+//
+// It calls /v4/info on Lavalink v4 and above, and /version on v3.
+func (node *Node) Info(ctx context.Context) (*Info, error) {
+	info := new(Info)
+	if node.APIVersion() >= 4 {
+		err := node.rest(ctx, "info", http.MethodGet, "/v4/info", nil, info)
+		if err != nil {
+			return nil, err
+		}
+		return info, nil
+	}
+
+	var version string
+	err := node.rest(ctx, "version", http.MethodGet, "/version", nil, &version)
+	if err != nil {
+		return nil, err
+	}
+	info.Version = parseVersion(strings.TrimSpace(version))
+	return info, nil
+}
+
+// parseVersion parses a semantic version like 3.7.8-rc.1+build
+func parseVersion(semver string) Version {
+	v := Version{Semver: semver}
+
+	rest := semver
+	if i := strings.Index(rest, "+"); i != -1 {
+		v.Build = rest[i+1:]
+		rest = rest[:i]
+	}
+	if i := strings.Index(rest, "-"); i != -1 {
+		v.PreRelease = rest[i+1:]
+		rest = rest[:i]
+	}
+
+	parts := strings.SplitN(rest, ".", 3)
+	fields := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		*fields[i], _ = strconv.Atoi(p)
+	}
+	return v
+}
@@ -0,0 +1,125 @@
+package gavalink
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// DefaultPingInterval is the PingInterval of nodes which don't set one
+	DefaultPingInterval = 30 * time.Second
+	// DefaultPongTimeout is the PongTimeout of nodes which don't set one
+	DefaultPongTimeout = 10 * time.Second
+)
+
+// pingInterval returns how often the node is pinged, or 0 if it isn't
+func (node *Node) pingInterval() time.Duration {
+	switch {
+	case node.config.PingInterval < 0:
+		return 0
+	case node.config.PingInterval == 0:
+		return DefaultPingInterval
+	}
+	return node.config.PingInterval
+}
+
+func (node *Node) pongTimeout() time.Duration {
+	if node.config.PongTimeout <= 0 {
+		return DefaultPongTimeout
+	}
+	return node.config.PongTimeout
+}
+
+// Ping returns the round trip time of the node's websocket, as measured
+// by the last ping, or 0 if it hasn't been measured yet
+func (node *Node) Ping() time.Duration {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	return node.ping
+}
+
+// startKeepalive arms the connection's read deadline and pong handler,
+// and starts pinging it and watching its stats
+//
+// The keepalive goroutine exits once done is closed. It closes the
+// connection if it finds it dead, which makes listen reconnect.
+func (node *Node) startKeepalive(ws *websocket.Conn, done <-chan struct{}) {
+	interval := node.pingInterval()
+	if interval > 0 {
+		node.extendDeadline(ws)
+		ws.SetPongHandler(func(data string) error {
+			if sent, err := strconv.ParseInt(data, 10, 64); err == nil {
+				node.mu.Lock()
+				node.ping = time.Since(time.Unix(0, sent))
+				node.mu.Unlock()
+			}
+			node.extendDeadline(ws)
+			return nil
+		})
+	}
+
+	if interval <= 0 && node.config.StatsTimeout <= 0 {
+		return
+	}
+
+	node.mu.Lock()
+	node.lastStats = time.Now()
+	node.mu.Unlock()
+
+	node.manager.wg.Add(1)
+	go node.keepalive(ws, interval, done)
+}
+
+func (node *Node) keepalive(ws *websocket.Conn, interval time.Duration, done <-chan struct{}) {
+	defer node.manager.wg.Done()
+
+	tick := interval
+	if tick <= 0 || (node.config.StatsTimeout > 0 && node.config.StatsTimeout < tick) {
+		tick = node.config.StatsTimeout
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	lastPing := time.Now()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			if timeout := node.config.StatsTimeout; timeout > 0 {
+				node.mu.Lock()
+				stale := now.Sub(node.lastStats)
+				node.mu.Unlock()
+				if stale > timeout {
+					node.logger().Warn("node stopped sending stats, reconnecting", "stale", stale)
+					ws.Close()
+					return
+				}
+			}
+
+			if interval <= 0 || now.Sub(lastPing) < interval {
+				continue
+			}
+			lastPing = now
+			data := []byte(strconv.FormatInt(now.UnixNano(), 10))
+			err := ws.WriteControl(websocket.PingMessage, data, now.Add(node.pongTimeout()))
+			if err != nil {
+				node.logger().Warn("couldn't ping node, reconnecting", "err", err)
+				ws.Close()
+				return
+			}
+		}
+	}
+}
+
+// extendDeadline gives the node until its next ping plus the pong
+// timeout to send something
+func (node *Node) extendDeadline(ws *websocket.Conn) {
+	interval := node.pingInterval()
+	if interval <= 0 || node.isClosed() {
+		return
+	}
+	ws.SetReadDeadline(time.Now().Add(interval + node.pongTimeout()))
+}
@@ -1,110 +1,424 @@
 package gavalink
 
 import (
+	"context"
 	"errors"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
-	"sort"
+	"sync"
+
+	"github.com/gorilla/websocket"
 )
 
 // Log sets the log.Logger gavalink will write to
+//
+// It is only used by managers which have no logger set through
+// SetLogger.
 var Log *log.Logger
 
 func init() {
 	Log = log.New(os.Stdout, "(gavalink) ", 0)
 }
 
+// Attribute keys gavalink annotates its log records with
+const (
+	logNode  = "node"
+	logGuild = "guild"
+	logOp    = "op"
+)
+
+// defaultLogger writes Info and above to Log
+var defaultLogger = slog.New(slog.NewTextHandler(logWriter{}, &slog.HandlerOptions{
+	ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+		// Log decides whether to print times
+		if a.Key == slog.TimeKey && len(groups) == 0 {
+			return slog.Attr{}
+		}
+		return a
+	},
+}))
+
+// logWriter writes to whichever logger Log is set to at the time
+type logWriter struct{}
+
+func (logWriter) Write(p []byte) (int, error) {
+	Log.Print(string(p))
+	return len(p), nil
+}
+
 // Lavalink manages a connection to Lavalink Nodes
 type Lavalink struct {
 	shards string
 	userID string
 
-	nodes   []Node
+	// mu guards nodes and players
+	mu      sync.RWMutex
+	nodes   []*Node
 	players map[string]*Player
-}
 
-var (
-	errNoNodes          = errors.New("No nodes present")
-	errNodeNotFound     = errors.New("Couldn't find that node")
-	errPlayerNotFound   = errors.New("Couldn't find a player for that guild")
-	errVolumeOutOfRange = errors.New("Volume is out of range, must be within [0, 1000]")
-	errInvalidVersion   = errors.New("This library requires Lavalink >= 3")
-	errUnknownPayload   = errors.New("Lavalink sent an unknown payload")
-	errNilHandler       = errors.New("You must provide an event handler. Use gavalink.DummyEventHandler if you wish to ignore events")
-)
+	// ctx is cancelled when the manager is closed
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 
-// NewLavalink creates a new Lavalink manager
-func NewLavalink(shards string, userID string) *Lavalink {
-	return &Lavalink{
-		shards: shards,
-		userID: userID,
-		/*		nodes:   make([]Node, 1),*/
-		players: make(map[string]*Player),
-	}
+	nodeHandler NodeEventHandler
+	log         *slog.Logger
+	obs         Observer
+	trc         Tracer
+
+	httpClient *http.Client
+	dialer     *websocket.Dialer
+	balancer   LoadBalancer
+	reconnect  ReconnectPolicy
+	cache      *trackCache
+	// historySize is the size of the players' histories
+	historySize int
+	registry    *Registry
+
+	// events buffers node events if they are delivered asynchronously;
+	// eventsMu guards closing it
+	events       chan NodeEvent
+	eventsMu     sync.RWMutex
+	eventsClosed bool
+	dispatchDone chan struct{}
+
+	gateway VoiceGateway
+	voiceMu sync.Mutex
+	voice   map[string]*voiceConn
+	joins   map[string]*voiceJoin
 }
 
 // AddNodes adds a node to the Lavalink manager
+//
+// Either every node is added, or none is: the configs are validated
+// before any node is opened, and if one fails to open, the others are
+// closed.
 func (lavalink *Lavalink) AddNodes(nodeConfigs ...NodeConfig) error {
-	nodes := make([]Node, len(nodeConfigs))
+	if lavalink.ctx.Err() != nil {
+		return ErrClosed
+	}
+
+	nodes := make([]*Node, len(nodeConfigs))
 	for i, c := range nodeConfigs {
-		n := Node{
-			config:  c,
-			manager: lavalink,
-		}
-		err := n.open()
+		n, err := newNode(lavalink, c)
 		if err != nil {
 			return err
 		}
+		if _, err := lavalink.Node(n.config.Name); err == nil {
+			return ErrDuplicateNode
+		}
+		for _, other := range nodes[:i] {
+			if other.config.Name == n.config.Name {
+				return ErrDuplicateNode
+			}
+		}
 		nodes[i] = n
 	}
+
+	for i, n := range nodes {
+		if err := n.open(); err != nil {
+			// none of the nodes are added, close those already opened
+			for _, opened := range nodes[:i] {
+				opened.close()
+			}
+			return err
+		}
+	}
+
+	lavalink.mu.Lock()
 	lavalink.nodes = append(lavalink.nodes, nodes...)
+	lavalink.mu.Unlock()
+
+	for _, n := range nodes {
+		lavalink.emit(NodeEvent{
+			Type: NodeConnected,
+			Node: n.config.Name,
+		})
+	}
 	return nil
 }
 
-// RemoveNode removes a node from the manager
-func (lavalink *Lavalink) removeNode(node *Node) error {
+// SetNodeEventHandler sets the handler receiving lifecycle events of this
+// manager's nodes
+//
+// The handler is called synchronously from the node's goroutine, unless
+// the manager was created with WithEventBuffer.
+func (lavalink *Lavalink) SetNodeEventHandler(handler NodeEventHandler) {
+	lavalink.mu.Lock()
+	lavalink.nodeHandler = handler
+	lavalink.mu.Unlock()
+}
+
+// SetLogger sets the logger this manager writes to
+//
+// Records are annotated with the node, guild and op they concern; the
+// raw websocket frames are logged at debug level, without their voice
+// tokens, session IDs and resume keys. If no logger is set, gavalink
+// writes to Log.
+func (lavalink *Lavalink) SetLogger(logger *slog.Logger) {
+	lavalink.mu.Lock()
+	lavalink.log = logger
+	lavalink.mu.Unlock()
+}
+
+func (lavalink *Lavalink) logger() *slog.Logger {
+	lavalink.mu.RLock()
+	defer lavalink.mu.RUnlock()
+
+	if lavalink.log == nil {
+		return defaultLogger
+	}
+	return lavalink.log
+}
+
+func (lavalink *Lavalink) emit(event NodeEvent) {
+	if lavalink.events != nil {
+		lavalink.eventsMu.RLock()
+		if !lavalink.eventsClosed {
+			lavalink.events <- event
+		}
+		lavalink.eventsMu.RUnlock()
+		return
+	}
+	lavalink.handleNodeEvent(event)
+}
+
+func (lavalink *Lavalink) handleNodeEvent(event NodeEvent) {
+	lavalink.mu.RLock()
+	handler := lavalink.nodeHandler
+	lavalink.mu.RUnlock()
+
+	if handler != nil {
+		handler.OnNodeEvent(event)
+	}
+}
+
+// dispatch delivers buffered node events until the buffer is closed
+func (lavalink *Lavalink) dispatch() {
+	defer close(lavalink.dispatchDone)
+	for event := range lavalink.events {
+		lavalink.handleNodeEvent(event)
+	}
+}
+
+// Nodes returns the nodes of this manager
+func (lavalink *Lavalink) Nodes() []*Node {
+	lavalink.mu.RLock()
+	defer lavalink.mu.RUnlock()
+
+	nodes := make([]*Node, len(lavalink.nodes))
+	copy(nodes, lavalink.nodes)
+	return nodes
+}
+
+// Node gets a node by its name
+func (lavalink *Lavalink) Node(name string) (*Node, error) {
+	lavalink.mu.RLock()
+	defer lavalink.mu.RUnlock()
+
+	for _, n := range lavalink.nodes {
+		if n.config.Name == name {
+			return n, nil
+		}
+	}
+	return nil, ErrNodeNotFound
+}
+
+// RemoveNode closes a node and removes it from the manager
+//
+// Players on the node stop playing; use DrainNode first to move them to
+// other nodes.
+func (lavalink *Lavalink) RemoveNode(name string) error {
+	node, err := lavalink.Node(name)
+	if err != nil {
+		return err
+	}
+	return lavalink.removeNode(node, nil)
+}
+
+// DrainNode stops new players from being created on a node, and moves
+// its existing players to the best remaining nodes
+//
+// Moved players resume their track where it was. The drained node stays
+// connected, so it can be removed once DrainNode returns.
+func (lavalink *Lavalink) DrainNode(name string) error {
+	node, err := lavalink.Node(name)
+	if err != nil {
+		return err
+	}
+
+	node.mu.Lock()
+	node.draining = true
+	node.mu.Unlock()
+
+	lavalink.mu.RLock()
+	var players []*Player
+	for _, p := range lavalink.players {
+		if p.Node() == node {
+			players = append(players, p)
+		}
+	}
+	lavalink.mu.RUnlock()
+
+	for _, p := range players {
+		target, err := lavalink.BestNode(p.voiceServer().Endpoint)
+		if err != nil {
+			return err
+		}
+		if err = p.move(target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeNode removes a node from the manager, cause is the error which
+// led to its removal, if any
+func (lavalink *Lavalink) removeNode(node *Node, cause error) error {
+	lavalink.mu.Lock()
 	idx := -1
 	for i, n := range lavalink.nodes {
-		if n == *node {
+		if n == node {
 			idx = i
 			break
 		}
 	}
 	if idx == -1 {
-		return errNodeNotFound
+		lavalink.mu.Unlock()
+		return ErrNodeNotFound
 	}
 
-	node.stop()
-
 	// temp var for easier reading
 	n := lavalink.nodes
 	z := len(n) - 1
 
 	n[idx] = n[z] // swap idx with last
+	n[z] = nil
 	n = n[:z]
 
 	lavalink.nodes = n
-	return nil
+	lavalink.mu.Unlock()
+
+	err := node.close()
+	if obs, ok := lavalink.observer().(NodeRemovalObserver); ok {
+		obs.ObserveNodeRemoved(node.config.Name)
+	}
+	lavalink.emit(NodeEvent{
+		Type: NodeRemoved,
+		Node: node.config.Name,
+		Err:  cause,
+	})
+	return err
 }
 
-// BestNode returns the Node with the lowest latency
-func (lavalink *Lavalink) BestNode() (*Node, error) {
-	if len(lavalink.nodes) < 1 {
-		return nil, errNoNodes
+// BestNode returns the Node picked by the manager's LoadBalancer, the
+// node with the lowest penalty by default
+//
+// hint may be a voice server endpoint or a voice region; when given,
+// nodes in that region are preferred, and nodes elsewhere are only
+// returned if none in the region is available.
+func (lavalink *Lavalink) BestNode(hint ...string) (*Node, error) {
+	var region string
+	if len(hint) > 0 && hint[0] != "" {
+		region = VoiceRegion(hint[0])
 	}
 
-	sort.SliceStable(lavalink.nodes, func(i, j int) bool {
-		return lavalink.nodes[i].load < lavalink.nodes[j].load
-	})
+	lavalink.mu.RLock()
+	nodes := make([]*Node, 0, len(lavalink.nodes))
+	for _, n := range lavalink.nodes {
+		if !n.Draining() {
+			nodes = append(nodes, n)
+		}
+	}
+	lavalink.mu.RUnlock()
 
-	return &lavalink.nodes[0], nil
+	if len(nodes) == 0 {
+		return nil, ErrNoNodes
+	}
+	best, err := lavalink.balancer.Pick(nodes, region)
+	if err != nil {
+		return nil, err
+	}
+	if best == nil {
+		return nil, ErrNoNodes
+	}
+	return best, nil
 }
 
 // GetPlayer gets a player for a guild
 func (lavalink *Lavalink) GetPlayer(guild string) (*Player, error) {
+	lavalink.mu.RLock()
 	p, ok := lavalink.players[guild]
+	lavalink.mu.RUnlock()
 	if !ok {
-		return nil, errPlayerNotFound
+		return nil, ErrPlayerNotFound
 	}
 	return p, nil
 }
+
+// Close shuts the manager down
+//
+// If destroyPlayers is set, every player is destroyed on its node first.
+// Every node's websocket is then closed with a close frame, and Close
+// waits until the manager's goroutines have exited, or ctx is done.
+//
+// The manager can't be used after it has been closed. Errors met while
+// shutting down are joined in the returned error.
+func (lavalink *Lavalink) Close(ctx context.Context, destroyPlayers bool) error {
+	var errs []error
+
+	if destroyPlayers {
+		lavalink.mu.RLock()
+		players := make([]*Player, 0, len(lavalink.players))
+		for _, p := range lavalink.players {
+			players = append(players, p)
+		}
+		lavalink.mu.RUnlock()
+
+		for _, p := range players {
+			if err := p.Destroy(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	// stops reconnects in flight
+	lavalink.cancel()
+
+	lavalink.mu.Lock()
+	nodes := lavalink.nodes
+	lavalink.nodes = nil
+	lavalink.mu.Unlock()
+
+	for _, n := range nodes {
+		if err := n.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		lavalink.wg.Wait()
+		if lavalink.events != nil {
+			// nodes are done emitting, deliver what's left
+			lavalink.eventsMu.Lock()
+			if !lavalink.eventsClosed {
+				lavalink.eventsClosed = true
+				close(lavalink.events)
+			}
+			lavalink.eventsMu.Unlock()
+			<-lavalink.dispatchDone
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, ctx.Err())
+	}
+
+	return errors.Join(errs...)
+}
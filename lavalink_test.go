@@ -0,0 +1,1331 @@
+package gavalink_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/foxbot/gavalink"
+	"github.com/gorilla/websocket"
+)
+
+// testServer is a fake Lavalink node which accepts websocket
+// connections and records the ops it receives
+type testServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	ops    []string
+	frames []string
+	conns  []*websocket.Conn
+	routes map[string]http.HandlerFunc
+}
+
+func (srv *testServer) Ops() []string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return append([]string(nil), srv.ops...)
+}
+
+// Frames returns the frames received, in the order they were received
+func (srv *testServer) Frames() []string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return append([]string(nil), srv.frames...)
+}
+
+// Send sends a text frame to every client, once one is connected
+func (srv *testServer) Send(t *testing.T, frame string) {
+	t.Helper()
+	srv.write(t, websocket.TextMessage, []byte(frame))
+}
+
+// SendBinary sends a binary frame to every client, once one is connected
+func (srv *testServer) SendBinary(t *testing.T, data []byte) {
+	t.Helper()
+	srv.write(t, websocket.BinaryMessage, data)
+}
+
+func (srv *testServer) write(t *testing.T, messageType int, data []byte) {
+	t.Helper()
+	waitFor(t, "a connection", func() bool {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		return len(srv.conns) > 0
+	})
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	for _, ws := range srv.conns {
+		if err := ws.WriteMessage(messageType, data); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// Connections returns the number of clients connected
+func (srv *testServer) Connections() int {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return len(srv.conns)
+}
+
+// Drop closes every connection, without a close frame
+func (srv *testServer) Drop() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	for _, ws := range srv.conns {
+		ws.Close()
+	}
+	srv.conns = nil
+}
+
+// Handle serves REST requests to path with handler, instead of the
+// defaults
+func (srv *testServer) Handle(path string, handler http.HandlerFunc) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.routes == nil {
+		srv.routes = make(map[string]http.HandlerFunc)
+	}
+	srv.routes[path] = handler
+}
+
+// waitFor polls cond until it's true, or fails the test after a while
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newTestServer(t *testing.T) *testServer {
+	srv := new(testServer)
+	srv.Server = httptest.NewServer(srv.handler(t))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTLSTestServer(t *testing.T) *testServer {
+	srv := new(testServer)
+	srv.Server = httptest.NewTLSServer(srv.handler(t))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (srv *testServer) handler(t *testing.T) http.Handler {
+	upgrader := websocket.Upgrader{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv.mu.Lock()
+		route := srv.routes[r.URL.Path]
+		srv.mu.Unlock()
+		if route != nil {
+			route(w, r)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/loadtracks":
+			w.Write([]byte(`{"loadType":"NO_MATCHES","tracks":[]}`))
+			return
+		case "/version":
+			w.Write([]byte("3.7.8-rc.1"))
+			return
+		}
+
+		header := http.Header{}
+		header.Set("Lavalink-Api-Version", "3")
+		ws, err := upgrader.Upgrade(w, r, header)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer ws.Close()
+		srv.mu.Lock()
+		srv.conns = append(srv.conns, ws)
+		srv.mu.Unlock()
+		defer func() {
+			srv.mu.Lock()
+			srv.conns = slices.DeleteFunc(srv.conns, func(c *websocket.Conn) bool { return c == ws })
+			srv.mu.Unlock()
+		}()
+		for {
+			_, data, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg struct {
+				Op string `json:"op"`
+			}
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Error(err)
+				return
+			}
+			srv.mu.Lock()
+			srv.ops = append(srv.ops, msg.Op)
+			srv.frames = append(srv.frames, string(data))
+			srv.mu.Unlock()
+		}
+	})
+}
+
+func testNodeConfig(srv *testServer, name string) gavalink.NodeConfig {
+	return gavalink.NodeConfig{
+		Name:      name,
+		REST:      srv.URL,
+		WebSocket: "ws" + strings.TrimPrefix(srv.URL, "http"),
+		Password:  "youshallnotpass",
+	}
+}
+
+// newTestPlayer creates a manager with a node of srv, and a player for
+// guild 1 on it
+func newTestPlayer(t *testing.T, srv *testServer, handler gavalink.EventHandler) (*gavalink.Lavalink, *gavalink.Player) {
+	t.Helper()
+	lavalink := gavalink.NewLavalink("1", "1")
+	t.Cleanup(func() { lavalink.Close(context.Background(), false) })
+	if err := lavalink.AddNodes(testNodeConfig(srv, "a")); err != nil {
+		t.Fatal(err)
+	}
+	node, err := lavalink.BestNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	player, err := node.CreatePlayer("1", "session", gavalink.VoiceServerUpdate{}, handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lavalink, player
+}
+
+// tracks decoded by the tests
+const (
+	// testStream is a YouTube stream
+	testStream = "QAAAkAIALGxvZmkgaGlwIGhvcCByYWRpbyAtIGJlYXRzIHRvIHJlbGF4L3N0dWR5IHRvAApDaGlsbGVkQ293f/////////8AC2hIVzFvWTI2a3hRAQEAK2h0dHBzOi8vd3d3LnlvdXR1YmUuY29tL3dhdGNoP3Y9aEhXMW9ZMjZreFEAB3lvdXR1YmUAAAAAAAAAAA=="
+	// testSong is the same track, 3:35 long instead of a stream
+	testSong = "QAAAkAIALGxvZmkgaGlwIGhvcCByYWRpbyAtIGJlYXRzIHRvIHJlbGF4L3N0dWR5IHRvAApDaGlsbGVkQ293AAAAAAADR9gAC2hIVzFvWTI2a3hRAAEAK2h0dHBzOi8vd3d3LnlvdXR1YmUuY29tL3dhdGNoP3Y9aEhXMW9ZMjZreFEAB3lvdXR1YmUAAAAAAAAAAA=="
+)
+
+func TestCloseLeavesNoGoroutines(t *testing.T) {
+	srv := newTestServer(t)
+	before := runtime.NumGoroutine()
+
+	var events []gavalink.NodeEventType
+	lavalink, err := gavalink.New("1",
+		gavalink.WithEventBuffer(4),
+		gavalink.WithNodeEventHandler(gavalink.NodeEventHandlerFunc(func(event gavalink.NodeEvent) {
+			events = append(events, event.Type)
+		})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lavalink.AddNodes(testNodeConfig(srv, "a"), testNodeConfig(srv, "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := lavalink.BestNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = node.CreatePlayer("1", "session", gavalink.VoiceServerUpdate{}, gavalink.DummyEventHandler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := lavalink.Close(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := lavalink.GetPlayer("1"); err == nil {
+		t.Error("player survived Close")
+	}
+	// every buffered event is delivered before Close returns
+	if len(events) != 4 {
+		t.Errorf("got events %v, want two connects and two disconnects", events)
+	}
+
+	// the fake server's handlers exit asynchronously once their
+	// connections close
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		buf := make([]byte, 1<<16)
+		t.Errorf("%d goroutines leaked:\n%s", n-before, buf[:runtime.Stack(buf, true)])
+	}
+}
+
+func TestAddNodesAtomically(t *testing.T) {
+	srv := newTestServer(t)
+
+	lavalink := gavalink.NewLavalink("1", "1")
+	defer lavalink.Close(context.Background(), false)
+
+	err := lavalink.AddNodes(testNodeConfig(srv, "a"), gavalink.NodeConfig{Name: "invalid"})
+	if !errors.Is(err, gavalink.ErrInvalidOption) {
+		t.Errorf("adding an invalid node returned %v", err)
+	}
+	if n := srv.Connections(); n != 0 {
+		t.Errorf("%d nodes were opened before validating every config", n)
+	}
+
+	// nothing listens on port 1
+	unreachable := gavalink.NodeConfig{Name: "unreachable", REST: "http://127.0.0.1:1", WebSocket: "ws://127.0.0.1:1"}
+	if err = lavalink.AddNodes(testNodeConfig(srv, "a"), unreachable); err == nil {
+		t.Error("adding an unreachable node succeeded")
+	}
+	waitFor(t, "node a to be closed", func() bool { return srv.Connections() == 0 })
+	if _, err = lavalink.Node("a"); err == nil {
+		t.Error("node a was added along with an unreachable node")
+	}
+}
+
+func TestDrainNode(t *testing.T) {
+	srvA, srvB := newTestServer(t), newTestServer(t)
+
+	lavalink := gavalink.NewLavalink("1", "1")
+	defer lavalink.Close(context.Background(), false)
+	if err := lavalink.AddNodes(testNodeConfig(srvA, "a"), testNodeConfig(srvB, "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := lavalink.Node("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	player, err := a.CreatePlayer("1", "session", gavalink.VoiceServerUpdate{}, gavalink.DummyEventHandler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = player.Play("track"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the player is read while it moves
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			player.Node()
+			lavalink.Snapshot()
+		}
+	}()
+	if err = lavalink.DrainNode("a"); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	if name := player.Node().Name(); name != "b" {
+		t.Errorf("player is on node %s after draining a", name)
+	}
+	if n, err := lavalink.BestNode(); err != nil || n.Name() != "b" {
+		t.Errorf("BestNode returned %v, %v while a is draining", n, err)
+	}
+
+	want := []string{"voiceUpdate", "play"}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(srvB.Ops()) < len(want) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if ops := srvB.Ops(); strings.Join(ops, ",") != strings.Join(want, ",") {
+		t.Errorf("node b received %v, want %v", ops, want)
+	}
+
+	if err = lavalink.RemoveNode("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = lavalink.Node("a"); err == nil {
+		t.Error("node a survived RemoveNode")
+	}
+}
+
+func TestStatsLoad(t *testing.T) {
+	srvA, srvB := newTestServer(t), newTestServer(t)
+
+	lavalink := gavalink.NewLavalink("1", "1")
+	defer lavalink.Close(context.Background(), false)
+	if err := lavalink.AddNodes(testNodeConfig(srvA, "a"), testNodeConfig(srvB, "b")); err != nil {
+		t.Fatal(err)
+	}
+	a, err := lavalink.Node("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nodes are scored while their stats arrive
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			lavalink.BestNode()
+		}
+	}()
+	srvA.Send(t, `{"op":"stats","players":1,"playingPlayers":1,"uptime":1000,"cpu":{"cores":4,"systemLoad":0.9,"lavalinkLoad":0.9}}`)
+	<-done
+
+	waitFor(t, "node a's stats", func() bool { return a.Stats() != nil })
+	if n, err := lavalink.BestNode(); err != nil || n.Name() != "b" {
+		t.Errorf("BestNode returned %v, %v while a is loaded", n, err)
+	}
+}
+
+func TestNewValidates(t *testing.T) {
+	tests := map[string][]gavalink.Option{
+		"user ID":     nil,
+		"shard count": {gavalink.WithShardCount(0)},
+		"cache size":  {gavalink.WithTrackCache(-1)},
+		"nil client":  {gavalink.WithHTTPClient(nil)},
+	}
+	for name, options := range tests {
+		userID := "1"
+		if options == nil {
+			userID = "not a snowflake"
+		}
+		if _, err := gavalink.New(userID, options...); !errors.Is(err, gavalink.ErrInvalidOption) {
+			t.Errorf("%s: got %v, want ErrInvalidOption", name, err)
+		}
+	}
+}
+
+func TestSecureNode(t *testing.T) {
+	srv := newTLSTestServer(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+
+	lavalink := gavalink.NewLavalink("1", "1")
+	defer lavalink.Close(context.Background(), false)
+	err := lavalink.AddNodes(gavalink.NodeConfig{
+		Name:      "tls",
+		Host:      srv.Listener.Addr().String(),
+		Secure:    true,
+		TLSConfig: &tls.Config{RootCAs: roots},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := lavalink.Node("tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracks, err := node.LoadTracks("ytsearch:lofi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tracks.Type != gavalink.NoMatches {
+		t.Errorf("got load type %s", tracks.Type)
+	}
+}
+
+// roundTripperFunc is a custom http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestCustomTransport(t *testing.T) {
+	srv := newTestServer(t)
+
+	client := &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}
+	lavalink, err := gavalink.New("1", gavalink.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lavalink.Close(context.Background(), false)
+
+	config := testNodeConfig(srv, "a")
+	config.TLSConfig = &tls.Config{}
+	if err = lavalink.AddNodes(config); !errors.Is(err, gavalink.ErrInvalidOption) {
+		t.Errorf("TLSConfig with a custom transport returned %v", err)
+	}
+	// the node's own client is used as it is
+	config.HTTPClient = client
+	if err = lavalink.AddNodes(config); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeepalive(t *testing.T) {
+	srv := newTestServer(t)
+
+	reconnected := make(chan struct{}, 1)
+	lavalink, err := gavalink.New("1", gavalink.WithNodeEventHandler(gavalink.NodeEventHandlerFunc(func(event gavalink.NodeEvent) {
+		if event.Type == gavalink.NodeReconnected {
+			select {
+			case reconnected <- struct{}{}:
+			default:
+			}
+		}
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lavalink.Close(context.Background(), false)
+
+	config := testNodeConfig(srv, "a")
+	config.PingInterval = 10 * time.Millisecond
+	// the fake server never sends stats
+	config.StatsTimeout = 100 * time.Millisecond
+	if err = lavalink.AddNodes(config); err != nil {
+		t.Fatal(err)
+	}
+	node, err := lavalink.Node("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for node.Ping() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if node.Ping() == 0 {
+		t.Error("ping wasn't measured")
+	}
+
+	select {
+	case <-reconnected:
+	case <-time.After(2 * time.Second):
+		t.Error("node with stale stats wasn't reconnected")
+	}
+}
+
+func TestNodeEvents(t *testing.T) {
+	srv := newTestServer(t)
+
+	var mu sync.Mutex
+	var events []gavalink.NodeEventType
+	lavalink, err := gavalink.New("1",
+		gavalink.WithReconnectPolicy(gavalink.ExponentialBackoff{Attempts: 1}),
+		gavalink.WithNodeEventHandler(gavalink.NodeEventHandlerFunc(func(event gavalink.NodeEvent) {
+			mu.Lock()
+			events = append(events, event.Type)
+			mu.Unlock()
+		})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lavalink.Close(context.Background(), false)
+	obs := new(testObserver)
+	lavalink.SetObserver(obs)
+	got := func() []gavalink.NodeEventType {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(events)
+	}
+
+	if err = lavalink.AddNodes(testNodeConfig(srv, "a")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the node to connect", func() bool { return srv.Connections() == 1 })
+	srv.Drop()
+	waitFor(t, "the node to reconnect", func() bool { return len(got()) == 3 })
+
+	// the node can't reconnect once the server refuses websockets
+	srv.Handle("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	srv.Drop()
+	waitFor(t, "the node to be removed", func() bool { return len(got()) == 5 })
+
+	want := []gavalink.NodeEventType{
+		gavalink.NodeConnected,
+		gavalink.NodeDisconnected,
+		gavalink.NodeReconnected,
+		gavalink.NodeDisconnected,
+		gavalink.NodeRemoved,
+	}
+	if !slices.Equal(got(), want) {
+		t.Errorf("got events %v, want %v", got(), want)
+	}
+	if _, err = lavalink.Node("a"); err == nil {
+		t.Error("node which couldn't reconnect wasn't removed")
+	}
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	if !slices.Equal(obs.removed, []string{"a"}) {
+		t.Errorf("observer was told of removed nodes %v, want a", obs.removed)
+	}
+}
+
+func TestLogRedactsSecrets(t *testing.T) {
+	srv := newTestServer(t)
+
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	lavalink := gavalink.NewLavalink("1", "1")
+	defer lavalink.Close(context.Background(), false)
+	lavalink.SetLogger(slog.New(slog.NewTextHandler(&lockedWriter{&mu, &buf}, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	if err := lavalink.AddNodes(testNodeConfig(srv, "a")); err != nil {
+		t.Fatal(err)
+	}
+	node, err := lavalink.BestNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = node.CreatePlayer("1", "voice-session", gavalink.VoiceServerUpdate{Token: "voice-token"}, gavalink.DummyEventHandler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	log := buf.String()
+	if !strings.Contains(log, "sending frame") {
+		t.Fatalf("frames weren't logged:\n%s", log)
+	}
+	for _, secret := range []string{"voice-session", "voice-token"} {
+		if strings.Contains(log, secret) {
+			t.Errorf("%s was logged:\n%s", secret, log)
+		}
+	}
+}
+
+// lockedWriter serializes writes to w
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
+func TestInfo(t *testing.T) {
+	srv := newTestServer(t)
+
+	lavalink := gavalink.NewLavalink("1", "1")
+	defer lavalink.Close(context.Background(), false)
+	if err := lavalink.AddNodes(testNodeConfig(srv, "a")); err != nil {
+		t.Fatal(err)
+	}
+	node, err := lavalink.Node("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := node.APIVersion(); v != 3 {
+		t.Errorf("got API version %d, want 3", v)
+	}
+	info, err := node.Info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gavalink.Version{Semver: "3.7.8-rc.1", Major: 3, Minor: 7, Patch: 8, PreRelease: "rc.1"}
+	if info.Version != want {
+		t.Errorf("got version %+v, want %+v", info.Version, want)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+
+	lavalink, player := newTestPlayer(t, srv, gavalink.DummyEventHandler{})
+	err := player.Play("track")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = player.Volume(50); err != nil {
+		t.Fatal(err)
+	}
+	player.Queue().Add(gavalink.Track{Data: "next"})
+
+	store := gavalink.FileStore{Path: t.TempDir() + "/players.json"}
+	if err = store.Save(ctx, lavalink.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if err = lavalink.Close(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newTestServer(t)
+	lavalink = gavalink.NewLavalink("1", "1")
+	defer lavalink.Close(ctx, false)
+	if err = lavalink.AddNodes(testNodeConfig(restored, "a")); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := store.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = lavalink.Restore(ctx, snapshot, func(string) gavalink.EventHandler {
+		return gavalink.DummyEventHandler{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player, err = lavalink.GetPlayer("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if player.Track() != "track" || player.GetVolume() != 50 || player.Queue().Len() != 1 {
+		t.Errorf("restored track %q, volume %d and %d queued", player.Track(), player.GetVolume(), player.Queue().Len())
+	}
+
+	want := []string{"voiceUpdate", "play", "volume"}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(restored.Ops()) < len(want) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if ops := restored.Ops(); strings.Join(ops, ",") != strings.Join(want, ",") {
+		t.Errorf("node received %v, want %v", ops, want)
+	}
+
+	empty, err := gavalink.FileStore{Path: t.TempDir() + "/missing.json"}.Load(ctx)
+	if err != nil || len(empty.Players) != 0 {
+		t.Errorf("loading a missing file returned %v, %v", empty, err)
+	}
+}
+
+func TestFadeVolume(t *testing.T) {
+	srv := newTestServer(t)
+	_, player := newTestPlayer(t, srv, gavalink.DummyEventHandler{})
+	player.SetFadeOptions(gavalink.FadeOptions{Curve: gavalink.EaseOut, Step: 10 * time.Millisecond})
+
+	err := player.FadeVolume(context.Background(), 0, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := player.GetVolume(); v != 0 {
+		t.Errorf("volume is %d after fading to 0", v)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(srv.Ops()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	ops := srv.Ops()
+	if len(ops) < 3 {
+		t.Fatalf("node received %v, want a voiceUpdate and several volume ops", ops)
+	}
+	for _, op := range ops[1:] {
+		if op != "volume" {
+			t.Errorf("node received %s while fading", op)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = player.FadeVolume(ctx, 100, time.Second); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled fade returned %v", err)
+	}
+}
+
+func TestFadeConcurrently(t *testing.T) {
+	srv := newTestServer(t)
+	_, player := newTestPlayer(t, srv, gavalink.DummyEventHandler{})
+	player.SetFadeOptions(gavalink.FadeOptions{Step: time.Millisecond, In: 50 * time.Millisecond, Out: 20 * time.Millisecond})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			player.FadeVolume(ctx, 50, 20*time.Millisecond)
+		}()
+		go func() {
+			defer wg.Done()
+			if err := player.Play(testSong); err != nil {
+				t.Error(err)
+			}
+			player.Seek(1000)
+			player.Stop()
+		}()
+		srv.Send(t, fmt.Sprintf(`{"op":"playerUpdate","guildId":"1","state":{"time":%d,"position":%d}}`, time.Now().UnixMilli(), i*1000))
+	}
+	wg.Wait()
+
+	// the racing fades leave the player usable
+	if err := player.FadeVolume(ctx, 30, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if v := player.GetVolume(); v != 30 {
+		t.Errorf("volume is %d after fading to 30", v)
+	}
+}
+
+func TestStopTransition(t *testing.T) {
+	srv := newTestServer(t)
+	_, player := newTestPlayer(t, srv, gavalink.DummyEventHandler{})
+	player.SetFadeOptions(gavalink.FadeOptions{Step: 10 * time.Millisecond, Transition: 2 * time.Second})
+	player.Queue().Add(gavalink.Track{Data: testStream})
+
+	if err := player.Play(testSong); err != nil {
+		t.Fatal(err)
+	}
+	// three seconds before the end of the song, so the transition is
+	// still fading when the player stops
+	srv.Send(t, fmt.Sprintf(`{"op":"playerUpdate","guildId":"1","state":{"time":%d,"position":212000}}`, time.Now().UnixMilli()))
+	waitFor(t, "the player update", func() bool { return player.Position() != 0 })
+	waitFor(t, "the transition", func() bool { return slices.Contains(srv.Ops(), "volume") })
+	if err := player.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	ops := srv.Ops()
+	if i := slices.Index(ops, "stop"); slices.Contains(ops[i:], "play") {
+		t.Errorf("node received %v, the transition played the queue after Stop", ops)
+	}
+	if player.Queue().Len() != 1 {
+		t.Error("the transition took a track from the queue after Stop")
+	}
+}
+
+func TestPlayResetsPosition(t *testing.T) {
+	srv := newTestServer(t)
+	_, player := newTestPlayer(t, srv, gavalink.DummyEventHandler{})
+
+	if err := player.Play(testSong); err != nil {
+		t.Fatal(err)
+	}
+	srv.Send(t, fmt.Sprintf(`{"op":"playerUpdate","guildId":"1","state":{"time":%d,"position":212000}}`, time.Now().UnixMilli()))
+	waitFor(t, "the player update", func() bool { return player.Position() != 0 })
+
+	if err := player.PlayAt(testSong, 5000, 0); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := player.Elapsed(); elapsed < 5*time.Second || elapsed > 6*time.Second {
+		t.Errorf("got elapsed %v after playing from 5s, want the new track's position", elapsed)
+	}
+}
+
+func TestPrevious(t *testing.T) {
+	srv := newTestServer(t)
+	_, player := newTestPlayer(t, srv, gavalink.DummyEventHandler{})
+
+	ctx := context.Background()
+	err := player.Previous(ctx)
+	if !errors.Is(err, gavalink.ErrNoHistory) {
+		t.Errorf("Previous without history returned %v", err)
+	}
+	for _, track := range []string{"a", "b"} {
+		if err = player.Play(track); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = player.Previous(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if player.Track() != "a" {
+		t.Errorf("playing %q, want a", player.Track())
+	}
+	if next, _ := player.Queue().Peek(); next.Data != "b" {
+		t.Errorf("queued %q, want b", next.Data)
+	}
+	if entries := player.History().Entries(); len(entries) != 1 || entries[0].Track != "a" {
+		t.Errorf("history is %v, want a", entries)
+	}
+}
+
+func TestHistoryReplay(t *testing.T) {
+	srv := newTestServer(t)
+	handler := trackEndHandler{reasons: make(chan string, 1)}
+	_, player := newTestPlayer(t, srv, handler)
+
+	for i := 0; i < 2; i++ {
+		if err := player.Play(testSong); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv.Send(t, fmt.Sprintf(`{"op":"event","type":"TrackEndEvent","guildId":"1","track":%q,"reason":"REPLACED"}`, testSong))
+	select {
+	case <-handler.reasons:
+	case <-time.After(2 * time.Second):
+		t.Fatal("TrackEnd wasn't handled")
+	}
+
+	// the replaced play ended, not the one playing
+	entries := player.History().Entries()
+	if len(entries) != 2 || entries[0].End.IsZero() || !entries[1].End.IsZero() {
+		t.Fatalf("history is %+v, want the first play ended", entries)
+	}
+	if err := player.Previous(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// only the play Previous started is left
+	if entries = player.History().Entries(); len(entries) != 1 || !entries[0].End.IsZero() {
+		t.Errorf("history is %+v after Previous, want the track playing", entries)
+	}
+}
+
+func TestResolveAll(t *testing.T) {
+	srvA, srvB := newTestServer(t), newTestServer(t)
+
+	lavalink := gavalink.NewLavalink("1", "1")
+	defer lavalink.Close(context.Background(), false)
+
+	results := lavalink.ResolveAll(context.Background(), []string{"a"})
+	if !errors.Is(results[0].Err, gavalink.ErrNoNodes) {
+		t.Errorf("resolving without nodes returned %v", results[0].Err)
+	}
+
+	if err := lavalink.AddNodes(testNodeConfig(srvA, "a"), testNodeConfig(srvB, "b")); err != nil {
+		t.Fatal(err)
+	}
+	queries := make([]string, 20)
+	for i := range queries {
+		queries[i] = "ytsearch:" + string(rune('a'+i))
+	}
+
+	var streamed int
+	results = lavalink.ResolveAll(context.Background(), queries,
+		gavalink.ResolveConcurrency(2),
+		gavalink.OnResolved(func(gavalink.ResolveResult) { streamed++ }),
+	)
+	if streamed != len(queries) {
+		t.Errorf("streamed %d results, want %d", streamed, len(queries))
+	}
+	for i, r := range results {
+		if r.Index != i || r.Query != queries[i] {
+			t.Errorf("result %d is for query %d %q", i, r.Index, r.Query)
+		}
+		if r.Err != nil || r.Tracks == nil || r.Tracks.Type != gavalink.NoMatches {
+			t.Errorf("result %d is %+v", i, r)
+		}
+	}
+}
+
+func TestResolveAllRetries(t *testing.T) {
+	srvA, srvB := newTestServer(t), newTestServer(t)
+
+	var mu sync.Mutex
+	loads := make(map[string]int)
+	failing := map[string]bool{"a": true}
+	for name, srv := range map[string]*testServer{"a": srvA, "b": srvB} {
+		name := name
+		srv.Handle("/loadtracks", func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			loads[name]++
+			if failing[name] {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(`{"loadType":"NO_MATCHES","tracks":[]}`))
+		})
+	}
+
+	lavalink := gavalink.NewLavalink("1", "1")
+	defer lavalink.Close(context.Background(), false)
+	if err := lavalink.AddNodes(testNodeConfig(srvA, "a"), testNodeConfig(srvB, "b")); err != nil {
+		t.Fatal(err)
+	}
+	queries := make([]string, 20)
+	for i := range queries {
+		queries[i] = "ytsearch:" + string(rune('a'+i))
+	}
+
+	// queries failing on a are retried on b, which loads the rest once a
+	// failed a few times in a row
+	for _, r := range lavalink.ResolveAll(context.Background(), queries, gavalink.ResolveConcurrency(1)) {
+		if r.Err != nil || r.Node != "b" {
+			t.Errorf("result %d is %+v, want it loaded by b", r.Index, r)
+		}
+	}
+	mu.Lock()
+	if loads["a"] > 3 {
+		t.Errorf("a loaded %d queries after failing, want 3 at most", loads["a"])
+	}
+	// once every node fails, the queries left fail without being loaded
+	loads = make(map[string]int)
+	failing["b"] = true
+	mu.Unlock()
+	for _, r := range lavalink.ResolveAll(context.Background(), queries, gavalink.ResolveConcurrency(1)) {
+		if r.Err == nil {
+			t.Errorf("result %d is %+v, want an error", r.Index, r)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if n := loads["a"] + loads["b"]; n >= len(queries) {
+		t.Errorf("loaded %d queries on failing nodes, want fewer than %d", n, len(queries))
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	srv := newTestServer(t)
+
+	lavalink := gavalink.NewLavalink("1", "1")
+	defer lavalink.Close(context.Background(), false)
+	config := testNodeConfig(srv, "a")
+	config.SourceRateLimits = map[string]gavalink.RateLimit{
+		"ytsearch:": {Rate: 20},
+	}
+	if err := lavalink.AddNodes(config); err != nil {
+		t.Fatal(err)
+	}
+	node, err := lavalink.Node("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err = node.LoadTracks("ytsearch:song"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 loads at 20 per second took %v", elapsed)
+	}
+	// other sources aren't limited
+	if _, err = node.LoadTracks("scsearch:song"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err = node.LoadTracksContext(ctx, "ytsearch:song"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("load past its deadline returned %v", err)
+	}
+}
+
+// testObserver records the REST latencies, rate limit waits and
+// removed nodes of a manager
+type testObserver struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	waits     []time.Duration
+	sources   []string
+	removed   []string
+}
+
+func (obs *testObserver) ObserveNodeRemoved(node string) {
+	obs.mu.Lock()
+	obs.removed = append(obs.removed, node)
+	obs.mu.Unlock()
+}
+
+func (obs *testObserver) ObserveREST(node string, route string, latency time.Duration, err error) {
+	obs.mu.Lock()
+	obs.latencies = append(obs.latencies, latency)
+	obs.mu.Unlock()
+}
+
+func (obs *testObserver) ObserveRateLimit(node string, source string, wait time.Duration) {
+	obs.mu.Lock()
+	obs.waits = append(obs.waits, wait)
+	obs.sources = append(obs.sources, source)
+	obs.mu.Unlock()
+}
+
+func (*testObserver) ObserveStats(string, gavalink.Stats)  {}
+func (*testObserver) ObserveReconnect(string)              {}
+func (*testObserver) ObserveOpSent(string, string)         {}
+func (*testObserver) ObserveEvent(string, string)          {}
+func (*testObserver) ObserveTrackException(string, string) {}
+
+func TestRateLimitLatency(t *testing.T) {
+	srv := newTestServer(t)
+
+	lavalink := gavalink.NewLavalink("1", "1")
+	defer lavalink.Close(context.Background(), false)
+	obs := new(testObserver)
+	lavalink.SetObserver(obs)
+	config := testNodeConfig(srv, "a")
+	config.RateLimit = gavalink.RateLimit{Rate: 10}
+	config.SourceRateLimits = map[string]gavalink.RateLimit{
+		"ytsearch:": {Rate: 10},
+	}
+	if err := lavalink.AddNodes(config); err != nil {
+		t.Fatal(err)
+	}
+	node, err := lavalink.Node("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range []string{"ytsearch:song", "ytsearch:song", "scsearch:song"} {
+		if _, err = node.LoadTracks(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	// the second load waits for the source, the third for the node
+	if len(obs.waits) != 2 {
+		t.Errorf("got rate limit waits %v, want 2", obs.waits)
+	}
+	for _, latency := range obs.latencies {
+		if latency >= 50*time.Millisecond {
+			t.Errorf("REST latency %v includes rate limit waits", latency)
+		}
+	}
+}
+
+// backoffRecorder is a slog.Handler which records the backoffs of
+// throttled loads
+type backoffRecorder struct {
+	mu       *sync.Mutex
+	backoffs *[]time.Duration
+}
+
+func (h backoffRecorder) Enabled(context.Context, slog.Level) bool { return true }
+func (h backoffRecorder) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h backoffRecorder) WithGroup(string) slog.Handler            { return h }
+
+func (h backoffRecorder) Handle(_ context.Context, r slog.Record) error {
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "backoff" {
+			h.mu.Lock()
+			*h.backoffs = append(*h.backoffs, a.Value.Duration())
+			h.mu.Unlock()
+		}
+		return true
+	})
+	return nil
+}
+
+func TestThrottleBackoff(t *testing.T) {
+	srv := newTestServer(t)
+
+	var mu sync.Mutex
+	response := `{"loadType":"NO_MATCHES","tracks":[]}`
+	respond := func(body string) {
+		mu.Lock()
+		response = body
+		mu.Unlock()
+	}
+	srv.Handle("/loadtracks", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if response == "" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(response))
+	})
+
+	var backoffs []time.Duration
+	lavalink := gavalink.NewLavalink("1", "1")
+	defer lavalink.Close(context.Background(), false)
+	lavalink.SetLogger(slog.New(backoffRecorder{&mu, &backoffs}))
+	obs := new(testObserver)
+	lavalink.SetObserver(obs)
+
+	a := testNodeConfig(srv, "a")
+	a.ThrottleBackoff = time.Millisecond
+	a.SourceRateLimits = map[string]gavalink.RateLimit{"ytsearch:": {}}
+	b := testNodeConfig(srv, "b")
+	b.ThrottleBackoff = 50 * time.Millisecond
+	if err := lavalink.AddNodes(a, b); err != nil {
+		t.Fatal(err)
+	}
+	nodeA, err := lavalink.Node("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeB, err := lavalink.Node("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// backoffs double while loads are throttled, up to 32 times the base
+	respond("")
+	for i := 0; i < 7; i++ {
+		if _, err = nodeA.LoadTracks("ytsearch:song"); err == nil {
+			t.Fatal("throttled load succeeded")
+		}
+	}
+	// a load which succeeds resets the backoff
+	respond(`{"loadType":"NO_MATCHES","tracks":[]}`)
+	if _, err = nodeA.LoadTracks("ytsearch:song"); err != nil {
+		t.Fatal(err)
+	}
+	respond(`{"loadType":"LOAD_FAILED","exception":{"message":"Sign in to confirm you're not a bot","severity":"COMMON"}}`)
+	if _, err = nodeA.LoadTracks("ytsearch:song"); !errors.Is(err, gavalink.ErrLoadFailed) {
+		t.Errorf("load which failed returned %v", err)
+	}
+
+	mu.Lock()
+	want := []time.Duration{1, 2, 4, 8, 16, 32, 32, 1}
+	for i := range want {
+		want[i] *= time.Millisecond
+	}
+	if !slices.Equal(backoffs, want) {
+		t.Errorf("backed off %v, want %v", backoffs, want)
+	}
+	backoffs = nil
+	mu.Unlock()
+
+	// sources without a limit of their own back off every such load
+	respond("")
+	if _, err = nodeB.LoadTracks("scsearch:song"); err == nil {
+		t.Fatal("throttled load succeeded")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err = nodeB.LoadTracksContext(ctx, "ytsearch:song"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("load during a backoff returned %v", err)
+	}
+	// other routes don't wait for loads to back off
+	infoCtx, cancelInfo := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelInfo()
+	if _, err = nodeB.Info(infoCtx); err != nil {
+		t.Errorf("getting the info during a load backoff returned %v", err)
+	}
+	respond(`{"loadType":"NO_MATCHES","tracks":[]}`)
+	if _, err = nodeB.LoadTracks("ytsearch:song"); err != nil {
+		t.Fatal(err)
+	}
+
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	last := len(obs.waits) - 1
+	if last < 0 || obs.sources[last] != "" || obs.waits[last] < 30*time.Millisecond {
+		t.Errorf("got rate limit waits %v for sources %q, want one for node b's backoff", obs.waits, obs.sources)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	srv := newTestServer(t)
+	handler := trackEndHandler{reasons: make(chan string, 1)}
+	_, player := newTestPlayer(t, srv, handler)
+	ctx := context.Background()
+
+	if err := player.Play(testSong); err != nil {
+		t.Fatal(err)
+	}
+	srv.Send(t, fmt.Sprintf(`{"op":"playerUpdate","guildId":"1","state":{"time":%d,"position":200000}}`, time.Now().UnixMilli()))
+	waitFor(t, "the player update", func() bool { return player.Position() != 0 })
+
+	if err := player.Replay(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// the node ends the play Replay replaced
+	srv.Send(t, fmt.Sprintf(`{"op":"event","type":"TrackEndEvent","guildId":"1","track":%q,"reason":"REPLACED"}`, testSong))
+	select {
+	case <-handler.reasons:
+	case <-time.After(2 * time.Second):
+		t.Fatal("TrackEnd wasn't handled")
+	}
+	if player.Track() != testSong {
+		t.Fatalf("got track %q after Replay, want it playing again", player.Track())
+	}
+
+	if err := player.SeekBy(ctx, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the seek", func() bool { return slices.Contains(srv.Ops(), "seek") })
+	frames := srv.Frames()
+	var seek struct {
+		Position int `json:"position"`
+	}
+	if err := json.Unmarshal([]byte(frames[len(frames)-1]), &seek); err != nil {
+		t.Fatal(err)
+	}
+	// seeking from the start of the replay, not 3:20 into the old play
+	if seek.Position < 10000 || seek.Position > 11000 {
+		t.Errorf("SeekBy 10s after Replay sought to %dms", seek.Position)
+	}
+}
+
+func TestPlayValidation(t *testing.T) {
+	srv := newTestServer(t)
+	_, player := newTestPlayer(t, srv, gavalink.DummyEventHandler{})
+	ctx := context.Background()
+
+	err := player.SeekTo(ctx, time.Second)
+	if !errors.Is(err, gavalink.ErrNoTrack) {
+		t.Errorf("seeking without a track returned %v", err)
+	}
+
+	var posErr *gavalink.PositionError
+	err = player.PlayWithOptions(ctx, testStream, gavalink.PlayOptions{Start: time.Minute})
+	if !errors.As(err, &posErr) || !errors.Is(err, gavalink.ErrNotSeekable) {
+		t.Errorf("starting a stream at 1:00 returned %v", err)
+	}
+	err = player.PlayWithOptions(ctx, testSong, gavalink.PlayOptions{Start: time.Minute, End: 4 * time.Minute})
+	if !errors.As(err, &posErr) || !errors.Is(err, gavalink.ErrPositionOutOfRange) || posErr.Position != 4*time.Minute {
+		t.Errorf("ending a 3:35 track at 4:00 returned %v", err)
+	}
+
+	volume := 50
+	err = player.PlayWithOptions(ctx, testSong, gavalink.PlayOptions{Start: time.Minute, Paused: true, Volume: &volume})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !player.Paused() || player.GetVolume() != 50 || player.Track() != testSong {
+		t.Errorf("player is paused %v, at volume %d, playing %q", player.Paused(), player.GetVolume(), player.Track())
+	}
+	err = player.PlayWithOptions(ctx, testStream, gavalink.PlayOptions{NoReplace: true})
+	if !errors.Is(err, gavalink.ErrAlreadyPlaying) || player.Track() != testSong {
+		t.Errorf("NoReplace replaced the track: %v", err)
+	}
+
+	if err = player.SeekBy(ctx, time.Hour); err != nil {
+		t.Errorf("seeking past the end returned %v", err)
+	}
+	if err = player.Restart(ctx); err != nil {
+		t.Error(err)
+	}
+	if err = player.SeekTo(ctx, 5*time.Minute); !errors.Is(err, gavalink.ErrPositionOutOfRange) {
+		t.Errorf("seeking to 5:00 of a 3:35 track returned %v", err)
+	}
+
+	if err = player.Play(testStream); err != nil {
+		t.Fatal(err)
+	}
+	if err = player.Restart(ctx); !errors.Is(err, gavalink.ErrNotSeekable) {
+		t.Errorf("restarting a stream returned %v", err)
+	}
+	if err = player.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err = player.Replay(ctx); err != nil || player.Track() != testStream {
+		t.Errorf("replaying the stream returned %v", err)
+	}
+
+	// a track fading in is faded to its volume, not played at it
+	player.SetFadeOptions(gavalink.FadeOptions{Step: 10 * time.Millisecond, In: 50 * time.Millisecond})
+	volume = 80
+	if err = player.PlayWithOptions(ctx, testSong, gavalink.PlayOptions{Volume: &volume}); err != nil {
+		t.Fatal(err)
+	}
+	if v := player.GetVolume(); v != 80 {
+		t.Errorf("volume is %d, want 80", v)
+	}
+	waitFor(t, "the fade in", func() bool {
+		frames := srv.Frames()
+		return len(frames) > 0 && strings.Contains(frames[len(frames)-1], `"volume":80`)
+	})
+	frames := srv.Frames()
+	for i := len(frames) - 1; i >= 0; i-- {
+		if strings.Contains(frames[i], `"op":"play"`) {
+			if strings.Contains(frames[i], `"volume"`) {
+				t.Errorf("the track fading in was played at its volume: %s", frames[i])
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package gavalink
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limiter is a token bucket, which lets through rate requests per
+// second on average, and bursts of up to burst requests
+//
+// A limiter with a rate of 0 lets everything through, unless it backs
+// off. A nil *limiter lets everything through.
+type limiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	// until is when the limiter stops backing off, strikes counts the
+	// throttled requests since the last one which succeeded
+	until   time.Time
+	strikes int
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes a token, and returns how long to wait until it may be
+// used
+func (l *limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	backoff := l.until.Sub(now)
+	if backoff < 0 {
+		backoff = 0
+	}
+	if l.rate <= 0 {
+		return backoff
+	}
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	if delay < backoff {
+		delay = backoff
+	}
+	return delay
+}
+
+// cancel returns a token taken by reserve, which went unused
+func (l *limiter) cancel() {
+	l.mu.Lock()
+	if l.rate > 0 {
+		l.tokens++
+	}
+	l.mu.Unlock()
+}
+
+// maxBackoffShift caps backoffs at 32 times the base backoff
+const maxBackoffShift = 5
+
+// throttle backs off after a throttled request, for twice as long as
+// the last time if nothing succeeded since
+func (l *limiter) throttle(base time.Duration) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	shift := l.strikes
+	if shift > maxBackoffShift {
+		shift = maxBackoffShift
+	}
+	l.strikes++
+	backoff := base << shift
+	if until := time.Now().Add(backoff); until.After(l.until) {
+		l.until = until
+	}
+	return backoff
+}
+
+// succeed resets the backoff after a request went through
+func (l *limiter) succeed() {
+	l.mu.Lock()
+	l.strikes = 0
+	l.mu.Unlock()
+}
+
+// wait blocks until a request may be sent, or ctx is done, and returns
+// how long it waited
+func (l *limiter) wait(ctx context.Context) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+
+	delay := l.reserve()
+	if delay == 0 {
+		return 0, nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		// no point in queueing, the token would come too late
+		l.cancel()
+		return 0, context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return delay, nil
+	case <-ctx.Done():
+		l.cancel()
+		return 0, ctx.Err()
+	}
+}
@@ -0,0 +1,399 @@
+// Package metrics exports the measurements of a gavalink manager
+//
+// A Collector is a gavalink.Observer which aggregates the stats nodes
+// report and the traffic gavalink sends them. It serves them in the
+// Prometheus text format, which OpenMetrics scrapers accept, or through
+// expvar, without depending on a Prometheus client library:
+//
+//	collector := metrics.New()
+//	lavalink.SetObserver(collector)
+//	http.Handle("/metrics", collector)
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foxbot/gavalink"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the REST latency
+// histogram buckets
+var DefaultBuckets = []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// labels is a pair of label values, e.g. a node and an op
+type labels [2]string
+
+// histogram is a cumulative latency histogram
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Collector collects the measurements of a gavalink manager
+//
+// Collector implements gavalink.Observer, gavalink.NodeRemovalObserver
+// and http.Handler.
+type Collector struct {
+	buckets []float64
+
+	mu         sync.Mutex
+	stats      map[string]gavalink.Stats
+	reconnects map[string]uint64
+	ops        map[labels]uint64
+	events     map[labels]uint64
+	exceptions map[labels]uint64
+	rest       map[labels]*histogram
+	restErrors map[labels]uint64
+	waits      map[labels]uint64
+	waitTime   map[labels]float64
+}
+
+// New creates a Collector using DefaultBuckets
+func New() *Collector {
+	return NewWithBuckets(DefaultBuckets)
+}
+
+// NewWithBuckets creates a Collector with custom REST latency histogram
+// buckets, in seconds
+func NewWithBuckets(buckets []float64) *Collector {
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	return &Collector{
+		buckets:    b,
+		stats:      make(map[string]gavalink.Stats),
+		reconnects: make(map[string]uint64),
+		ops:        make(map[labels]uint64),
+		events:     make(map[labels]uint64),
+		exceptions: make(map[labels]uint64),
+		rest:       make(map[labels]*histogram),
+		restErrors: make(map[labels]uint64),
+		waits:      make(map[labels]uint64),
+		waitTime:   make(map[labels]float64),
+	}
+}
+
+// ObserveStats records the stats a node reported
+func (c *Collector) ObserveStats(node string, stats gavalink.Stats) {
+	c.mu.Lock()
+	c.stats[node] = stats
+	c.mu.Unlock()
+}
+
+// ObserveReconnect counts a node reconnect
+func (c *Collector) ObserveReconnect(node string) {
+	c.mu.Lock()
+	c.reconnects[node]++
+	c.mu.Unlock()
+}
+
+// ObserveREST records the latency of a REST request
+func (c *Collector) ObserveREST(node string, route string, latency time.Duration, err error) {
+	l := labels{node, route}
+	seconds := latency.Seconds()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h, ok := c.rest[l]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(c.buckets))}
+		c.rest[l] = h
+	}
+	for i, b := range c.buckets {
+		if seconds <= b {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+
+	if err != nil {
+		c.restErrors[l]++
+	}
+}
+
+// ObserveRateLimit records the time a REST request waited for a rate
+// limit
+func (c *Collector) ObserveRateLimit(node string, source string, wait time.Duration) {
+	l := labels{node, source}
+
+	c.mu.Lock()
+	c.waits[l]++
+	c.waitTime[l] += wait.Seconds()
+	c.mu.Unlock()
+}
+
+// ObserveOpSent counts an op sent to a node
+func (c *Collector) ObserveOpSent(node string, op string) {
+	c.mu.Lock()
+	c.ops[labels{node, op}]++
+	c.mu.Unlock()
+}
+
+// ObserveEvent counts an event a node sent
+func (c *Collector) ObserveEvent(node string, event string) {
+	c.mu.Lock()
+	c.events[labels{node, event}]++
+	c.mu.Unlock()
+}
+
+// ObserveTrackException counts a track exception
+func (c *Collector) ObserveTrackException(node string, severity string) {
+	c.mu.Lock()
+	c.exceptions[labels{node, severity}]++
+	c.mu.Unlock()
+}
+
+// ObserveNodeRemoved drops the series of a node removed from the
+// manager, so they aren't exported anymore
+func (c *Collector) ObserveNodeRemoved(node string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.stats, node)
+	delete(c.reconnects, node)
+	for _, m := range []map[labels]uint64{c.ops, c.events, c.exceptions, c.restErrors, c.waits} {
+		deleteNode(m, node)
+	}
+	deleteNode(c.rest, node)
+	deleteNode(c.waitTime, node)
+}
+
+// deleteNode deletes the values of a node from a labelled map
+func deleteNode[V any](m map[labels]V, node string) {
+	for l := range m {
+		if l[0] == node {
+			delete(m, l)
+		}
+	}
+}
+
+// ServeHTTP serves the collected metrics in the Prometheus text format
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+// WriteTo writes the collected metrics in the Prometheus text format
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := &encoder{w: w}
+
+	gauges := []struct {
+		name, help string
+		value      func(s gavalink.Stats) float64
+	}{
+		{"players", "Players on the node.", func(s gavalink.Stats) float64 { return float64(s.Players) }},
+		{"playing_players", "Players playing a track on the node.", func(s gavalink.Stats) float64 { return float64(s.PlayingPlayers) }},
+		{"uptime_seconds", "Uptime of the node.", func(s gavalink.Stats) float64 { return float64(s.Uptime) / 1000 }},
+		{"cpu_cores", "CPU cores of the node.", func(s gavalink.Stats) float64 { return float64(s.CPU.Cores) }},
+		{"cpu_system_load", "System CPU load of the node.", func(s gavalink.Stats) float64 { return float64(s.CPU.SystemLoad) }},
+		{"cpu_lavalink_load", "Lavalink CPU load of the node.", func(s gavalink.Stats) float64 { return float64(s.CPU.LavalinkLoad) }},
+		{"memory_free_bytes", "Free memory of the node.", func(s gavalink.Stats) float64 { return float64(s.Memory.Free) }},
+		{"memory_used_bytes", "Used memory of the node.", func(s gavalink.Stats) float64 { return float64(s.Memory.Used) }},
+		{"memory_allocated_bytes", "Allocated memory of the node.", func(s gavalink.Stats) float64 { return float64(s.Memory.Allocated) }},
+		{"memory_reservable_bytes", "Reservable memory of the node.", func(s gavalink.Stats) float64 { return float64(s.Memory.Reservable) }},
+		{"frames_sent", "Frames sent per player in the last minute.", frameStat(func(f *gavalink.StatsFrame) int { return f.Sent })},
+		{"frames_nulled", "Frames nulled per player in the last minute.", frameStat(func(f *gavalink.StatsFrame) int { return f.Nulled })},
+		{"frames_deficit", "Frame deficit per player in the last minute.", frameStat(func(f *gavalink.StatsFrame) int { return f.Deficit })},
+	}
+	nodes := sortedKeys(c.stats)
+	for _, g := range gauges {
+		name := "gavalink_node_" + g.name
+		e.header(name, "gauge", g.help)
+		for _, n := range nodes {
+			e.sample(name, []string{"node", n}, g.value(c.stats[n]))
+		}
+	}
+
+	e.header("gavalink_node_reconnects_total", "counter", "Reconnects of the node.")
+	for _, n := range sortedKeys(c.reconnects) {
+		e.sample("gavalink_node_reconnects_total", []string{"node", n}, float64(c.reconnects[n]))
+	}
+
+	e.counter("gavalink_ops_sent_total", "Websocket ops sent to the node.", "op", c.ops)
+	e.counter("gavalink_events_received_total", "Player events received from the node.", "event", c.events)
+	e.counter("gavalink_track_exceptions_total", "Track exceptions by severity.", "severity", c.exceptions)
+	e.counter("gavalink_rest_errors_total", "Failed REST requests to the node.", "route", c.restErrors)
+
+	e.counter("gavalink_rate_limit_waits_total", "REST requests which waited for a rate limit.", "source", c.waits)
+	name := "gavalink_rate_limit_wait_seconds_total"
+	e.header(name, "counter", "Time REST requests waited for a rate limit.")
+	for _, l := range sortedLabels(c.waitTime) {
+		e.sample(name, []string{"node", l[0], "source", l[1]}, c.waitTime[l])
+	}
+
+	name = "gavalink_rest_request_duration_seconds"
+	e.header(name, "histogram", "Latency of REST requests to the node.")
+	for _, l := range sortedLabels(c.rest) {
+		h := c.rest[l]
+		for i, b := range c.buckets {
+			le := fmt.Sprint(b)
+			e.sample(name+"_bucket", []string{"node", l[0], "route", l[1], "le", le}, float64(h.counts[i]))
+		}
+		e.sample(name+"_bucket", []string{"node", l[0], "route", l[1], "le", "+Inf"}, float64(h.count))
+		e.sample(name+"_sum", []string{"node", l[0], "route", l[1]}, h.sum)
+		e.sample(name+"_count", []string{"node", l[0], "route", l[1]}, float64(h.count))
+	}
+
+	return e.n, e.err
+}
+
+// Publish publishes the collected metrics as an expvar map under name
+//
+// Like expvar.Publish, it panics if name is already in use.
+func (c *Collector) Publish(name string) {
+	expvar.Publish(name, expvar.Func(c.expvar))
+}
+
+func (c *Collector) expvar() interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	type restVar struct {
+		Count   uint64  `json:"count"`
+		Errors  uint64  `json:"errors"`
+		Seconds float64 `json:"seconds"`
+	}
+	type waitVar struct {
+		Count   uint64  `json:"count"`
+		Seconds float64 `json:"seconds"`
+	}
+	type nodeVar struct {
+		Stats      *gavalink.Stats    `json:"stats,omitempty"`
+		Reconnects uint64             `json:"reconnects"`
+		Ops        map[string]uint64  `json:"ops"`
+		Events     map[string]uint64  `json:"events"`
+		Exceptions map[string]uint64  `json:"exceptions"`
+		REST       map[string]restVar `json:"rest"`
+		Waits      map[string]waitVar `json:"rateLimitWaits"`
+	}
+
+	nodes := make(map[string]*nodeVar)
+	node := func(name string) *nodeVar {
+		n, ok := nodes[name]
+		if !ok {
+			n = &nodeVar{
+				Ops:        make(map[string]uint64),
+				Events:     make(map[string]uint64),
+				Exceptions: make(map[string]uint64),
+				REST:       make(map[string]restVar),
+				Waits:      make(map[string]waitVar),
+			}
+			nodes[name] = n
+		}
+		return n
+	}
+
+	for name, s := range c.stats {
+		s := s
+		node(name).Stats = &s
+	}
+	for name, v := range c.reconnects {
+		node(name).Reconnects = v
+	}
+	for l, v := range c.ops {
+		node(l[0]).Ops[l[1]] = v
+	}
+	for l, v := range c.events {
+		node(l[0]).Events[l[1]] = v
+	}
+	for l, v := range c.exceptions {
+		node(l[0]).Exceptions[l[1]] = v
+	}
+	for l, h := range c.rest {
+		node(l[0]).REST[l[1]] = restVar{
+			Count:   h.count,
+			Errors:  c.restErrors[l],
+			Seconds: h.sum,
+		}
+	}
+	for l, v := range c.waits {
+		node(l[0]).Waits[l[1]] = waitVar{
+			Count:   v,
+			Seconds: c.waitTime[l],
+		}
+	}
+	return nodes
+}
+
+func frameStat(f func(*gavalink.StatsFrame) int) func(gavalink.Stats) float64 {
+	return func(s gavalink.Stats) float64 {
+		if s.FrameStats == nil {
+			return 0
+		}
+		return float64(f(s.FrameStats))
+	}
+}
+
+// encoder writes the Prometheus text format, keeping the first error
+type encoder struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (e *encoder) printf(format string, args ...interface{}) {
+	if e.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(e.w, format, args...)
+	e.n += int64(n)
+	e.err = err
+}
+
+func (e *encoder) header(name, typ, help string) {
+	e.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes a sample; labels alternates label names and values
+func (e *encoder) sample(name string, labels []string, value float64) {
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1])))
+	}
+	e.printf("%s{%s} %v\n", name, strings.Join(pairs, ","), value)
+}
+
+func (e *encoder) counter(name, help, label string, values map[labels]uint64) {
+	e.header(name, "counter", help)
+	for _, l := range sortedLabels(values) {
+		e.sample(name, []string{"node", l[0], label, l[1]}, float64(values[l]))
+	}
+}
+
+// labelEscaper escapes label values as the text format expects
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedLabels[V any](m map[labels]V) []labels {
+	keys := make([]labels, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
@@ -0,0 +1,89 @@
+package metrics_test
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxbot/gavalink"
+	"github.com/foxbot/gavalink/metrics"
+)
+
+func TestCollector(t *testing.T) {
+	c := metrics.NewWithBuckets([]float64{.1, 1})
+	c.ObserveStats("a", gavalink.Stats{
+		Players:        3,
+		PlayingPlayers: 2,
+		CPU:            gavalink.StatsCPU{LavalinkLoad: .5},
+		FrameStats:     &gavalink.StatsFrame{Deficit: 7},
+	})
+	c.ObserveReconnect("a")
+	c.ObserveOpSent("a", "play")
+	c.ObserveOpSent("a", "play")
+	c.ObserveEvent("a", "TrackEndEvent")
+	c.ObserveTrackException("a", "FAULT")
+	c.ObserveREST("a", "loadtracks", 500*time.Millisecond, nil)
+	c.ObserveREST("a", "loadtracks", 2*time.Second, errors.New("timeout"))
+	c.ObserveRateLimit("a", "ytsearch:", 1500*time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`gavalink_node_players{node="a"} 3`,
+		`gavalink_node_playing_players{node="a"} 2`,
+		`gavalink_node_cpu_lavalink_load{node="a"} 0.5`,
+		`gavalink_node_frames_deficit{node="a"} 7`,
+		`gavalink_node_reconnects_total{node="a"} 1`,
+		`gavalink_ops_sent_total{node="a",op="play"} 2`,
+		`gavalink_events_received_total{node="a",event="TrackEndEvent"} 1`,
+		`gavalink_track_exceptions_total{node="a",severity="FAULT"} 1`,
+		`gavalink_rest_errors_total{node="a",route="loadtracks"} 1`,
+		`gavalink_rate_limit_waits_total{node="a",source="ytsearch:"} 1`,
+		`gavalink_rate_limit_wait_seconds_total{node="a",source="ytsearch:"} 1.5`,
+		`gavalink_rest_request_duration_seconds_bucket{node="a",route="loadtracks",le="0.1"} 0`,
+		`gavalink_rest_request_duration_seconds_bucket{node="a",route="loadtracks",le="1"} 1`,
+		`gavalink_rest_request_duration_seconds_bucket{node="a",route="loadtracks",le="+Inf"} 2`,
+		`gavalink_rest_request_duration_seconds_count{node="a",route="loadtracks"} 2`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("missing %s in:\n%s", want, body)
+		}
+	}
+
+	c.Publish("gavalink_test")
+	var vars map[string]struct {
+		Reconnects uint64            `json:"reconnects"`
+		Ops        map[string]uint64 `json:"ops"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("gavalink_test").String()), &vars); err != nil {
+		t.Fatal(err)
+	}
+	if a := vars["a"]; a.Reconnects != 1 || a.Ops["play"] != 2 {
+		t.Errorf("unexpected expvar %+v", a)
+	}
+}
+
+func TestCollectorNodeRemoved(t *testing.T) {
+	c := metrics.New()
+	for _, node := range []string{"a", "b"} {
+		c.ObserveStats(node, gavalink.Stats{Players: 1})
+		c.ObserveOpSent(node, "play")
+		c.ObserveREST(node, "loadtracks", time.Millisecond, nil)
+		c.ObserveRateLimit(node, "", time.Millisecond)
+	}
+	c.ObserveNodeRemoved("a")
+
+	var b strings.Builder
+	if _, err := c.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	if body := b.String(); strings.Contains(body, `node="a"`) || !strings.Contains(body, `gavalink_node_players{node="b"} 1`) {
+		t.Errorf("got metrics of a removed node, or none of the remaining one:\n%s", body)
+	}
+}
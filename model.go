@@ -22,6 +22,8 @@ type Tracks struct {
 	Type         string        `json:"loadType"`
 	PlaylistInfo *PlaylistInfo `json:"playlistInfo"`
 	Tracks       []Track       `json:"tracks"`
+	// Exception is the exception a LoadFailed load ran into
+	Exception *Exception `json:"exception,omitempty"`
 }
 
 // PlaylistInfo contains information about a loaded playlist
@@ -50,6 +52,9 @@ type TrackInfo struct {
 	Stream     bool   `json:"isStream"`
 	Length     int    `json:"length"`
 	Position   int    `json:"position"`
+	// SourceName is the source manager which loaded the track, e.g.
+	// `youtube` or `soundcloud`
+	SourceName string `json:"sourceName,omitempty"`
 }
 
 const (
@@ -63,6 +68,8 @@ const (
 	opPlayerUpdate      = "playerUpdate"
 	opEvent             = "event"
 	opStats             = "stats"
+	opReady             = "ready"
+	opConfigureResuming = "configureResuming"
 	eventTrackEnd       = "TrackEndEvent"
 	eventTrackException = "TrackExceptionEvent"
 	eventTrackStuck     = "TrackStuckEvent"
@@ -76,6 +83,7 @@ type message struct {
 	Track       string             `json:"track,omitempty"`
 	StartTime   string             `json:"startTime,omitempty"`
 	EndTime     string             `json:"endTime,omitempty"`
+	NoReplace   bool               `json:"noReplace,omitempty"`
 	Pause       *bool              `json:"pause,omitempty"`
 	Position    *int               `json:"position,omitempty"`
 	Volume      *int               `json:"volume,omitempty"`
@@ -84,17 +92,62 @@ type message struct {
 	Reason      string             `json:"reason,omitempty"`
 	Error       string             `json:"error,omitempty"`
 	ThresholdMs int                `json:"thresholdMs,omitempty"`
-	StatCPU     *statCPU           `json:"cpu,omitempty"`
-	// TODO: stats
+	Exception   *Exception         `json:"exception,omitempty"`
+	Resumed     bool               `json:"resumed,omitempty"`
+	Key         string             `json:"key,omitempty"`
+	Timeout     int                `json:"timeout,omitempty"`
+	*Stats
 }
 
 type state struct {
-	Time     int `json:"time"`
-	Position int `json:"position"`
+	Time     int64 `json:"time"`
+	Position int   `json:"position"`
 }
 
-type statCPU struct {
-	Load float32 `json:"lavalinkLoad"`
+// Stats contains the statistics a Lavalink Node reports every minute
+type Stats struct {
+	// Players is the amount of players on the node
+	Players int `json:"players"`
+	// PlayingPlayers is the amount of players playing a track
+	PlayingPlayers int `json:"playingPlayers"`
+	// Uptime is the node's uptime, in millis
+	Uptime     int64       `json:"uptime"`
+	Memory     StatsMemory `json:"memory"`
+	CPU        StatsCPU    `json:"cpu"`
+	FrameStats *StatsFrame `json:"frameStats,omitempty"`
+}
+
+// StatsMemory contains the memory usage of a Lavalink Node, in bytes
+type StatsMemory struct {
+	Free       int64 `json:"free"`
+	Used       int64 `json:"used"`
+	Allocated  int64 `json:"allocated"`
+	Reservable int64 `json:"reservable"`
+}
+
+// StatsCPU contains the CPU usage of a Lavalink Node
+type StatsCPU struct {
+	Cores        int     `json:"cores"`
+	SystemLoad   float32 `json:"systemLoad"`
+	LavalinkLoad float32 `json:"lavalinkLoad"`
+}
+
+// StatsFrame contains the audio frames a Lavalink Node sent in the last
+// minute, averaged per player
+//
+// Lavalink omits frame stats when no player is playing.
+type StatsFrame struct {
+	Sent    int `json:"sent"`
+	Nulled  int `json:"nulled"`
+	Deficit int `json:"deficit"`
+}
+
+// Exception describes an exception Lavaplayer ran into
+type Exception struct {
+	Message string `json:"message"`
+	// Severity is one of COMMON, SUSPICIOUS or FAULT
+	Severity string `json:"severity"`
+	Cause    string `json:"cause"`
 }
 
 // VoiceServerUpdate is a raw Discord VOICE_SERVER_UPDATE event
@@ -103,3 +156,30 @@ type VoiceServerUpdate struct {
 	Endpoint string `json:"endpoint"`
 	Token    string `json:"token"`
 }
+
+// VoiceState is a raw Discord VOICE_STATE_UPDATE event, trimmed to the
+// fields gavalink needs
+type VoiceState struct {
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id"`
+	UserID    string `json:"user_id"`
+	SessionID string `json:"session_id"`
+}
+
+// VoiceGateway is the minimal part of a Discord gateway connection that
+// gavalink depends on
+//
+// Implement this to use gavalink with any Discord library: the
+// implementation sends OP 4 Voice State Updates on gavalink's behalf, and
+// feeds the voice events it receives back through the registered
+// callbacks.
+type VoiceGateway interface {
+	// UpdateVoiceState sends an OP 4 Voice State Update for a guild
+	//
+	// An empty channelID requests to leave the guild's voice channel,
+	// and must be sent as a null channel_id, not an empty string.
+	UpdateVoiceState(guildID string, channelID string, selfMute bool, selfDeaf bool) error
+	// HandleVoiceEvents registers the callbacks gavalink consumes
+	// VOICE_STATE_UPDATE and VOICE_SERVER_UPDATE events through
+	HandleVoiceEvents(onState func(VoiceState), onServer func(VoiceServerUpdate))
+}
@@ -1,17 +1,30 @@
 package gavalink
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
 // NodeConfig configures a Lavalink Node
 type NodeConfig struct {
+	// Name identifies the Node within its Lavalink manager
+	//
+	// Names must be unique. If empty, the WebSocket host is used.
+	Name string
 	// REST is the host where Lavalink's REST server runs
 	//
 	// This value is expected without a trailing slash, e.g. like
@@ -24,95 +37,612 @@ type NodeConfig struct {
 	WebSocket string
 	// Password is the expected Authorization header for the Node
 	Password string
+	// Regions lists the Discord voice regions this node serves, as
+	// VoiceRegion names them
+	//
+	// BestNode prefers nodes in the region of a player's voice server.
+	// Discord's current endpoints name their location, e.g. `ams` for
+	// `c-ams05-a1b2c3d4.discord.media`, while older ones name a region,
+	// e.g. `us-east` or `rotterdam`; list both to match either.
+	Regions []string
+
+	// Host is the host:port Lavalink runs on, e.g. `lavalink:2333`
+	//
+	// If set, REST and WebSocket default to URLs derived from Host.
+	Host string
+	// Secure derives `https://` and `wss://` URLs from Host, instead of
+	// `http://` and `ws://`
+	Secure bool
+
+	// Dialer opens the node's websocket, instead of the manager's dialer
+	Dialer *websocket.Dialer
+	// HTTPClient sends the node's REST requests, instead of the
+	// manager's client
+	HTTPClient *http.Client
+
+	// TLSConfig configures TLS for the node, e.g. with a custom CA or a
+	// client certificate
+	//
+	// Like Proxy, EnableCompression and HandshakeTimeout, it is ignored by
+	// Dialer and HTTPClient if they are set, and applied to copies of the
+	// manager's dialer and client otherwise. The manager's client must
+	// then use an *http.Transport.
+	TLSConfig *tls.Config
+	// Proxy returns the proxy to reach the node through, see
+	// http.ProxyFromEnvironment
+	Proxy func(*http.Request) (*url.URL, error)
+	// EnableCompression negotiates websocket compression with the node
+	EnableCompression bool
+	// HandshakeTimeout limits the websocket handshake with the node
+	HandshakeTimeout time.Duration
+
+	// PingInterval is how often the node is pinged to detect dead
+	// connections, DefaultPingInterval if 0; negative values disable pings
+	PingInterval time.Duration
+	// PongTimeout is how long the node may take to answer a ping before
+	// it is reconnected, DefaultPongTimeout if 0
+	PongTimeout time.Duration
+	// StatsTimeout reconnects the node if it hasn't sent stats for this
+	// long, if set
+	//
+	// Lavalink sends stats every minute, so a few minutes are reasonable.
+	StatsTimeout time.Duration
+
+	// RateLimit limits the REST requests sent to the node
+	//
+	// Requests over the limit wait for their turn, or fail if their
+	// context is done first.
+	RateLimit RateLimit
+	// SourceRateLimits limits the loads of queries starting with a
+	// prefix, e.g. `ytsearch:` or `https://www.youtube.com/`, on top of
+	// RateLimit
+	//
+	// Queries matching several prefixes are limited by the longest one.
+	SourceRateLimits map[string]RateLimit
+	// ThrottleBackoff is how long loads back off once a source throttles
+	// the node, DefaultThrottleBackoff if 0
+	//
+	// Loads failing with an HTTP 429 or a YouTube "Sign in to confirm"
+	// back off their source, or every load of the node without a source
+	// limit if their source has none; the backoff doubles as long as
+	// loads keep failing. Other REST routes don't back off.
+	ThrottleBackoff time.Duration
+
+	// ResumeKey lets the node keep its players while it is disconnected,
+	// so a restarted process can reattach to them with Restore
+	//
+	// Keys should be unique per process and node, and stable across
+	// restarts.
+	ResumeKey string
+	// ResumeTimeout is how long the node keeps its players after
+	// disconnecting, DefaultResumeTimeout if 0
+	ResumeTimeout time.Duration
 }
 
+// DefaultResumeTimeout is how long a node with a ResumeKey keeps its
+// players by default
+const DefaultResumeTimeout = time.Minute
+
+// closeTimeout is how long a node waits for Lavalink to acknowledge
+// its close frame
+const closeTimeout = 5 * time.Second
+
 // Node wraps a Lavalink Node
 type Node struct {
-	config  NodeConfig
-	load    float32
-	manager *Lavalink
-	wsConn  *websocket.Conn
+	config     NodeConfig
+	manager    *Lavalink
+	wsConn     *websocket.Conn
+	dialer     *websocket.Dialer
+	httpClient *http.Client
+
+	// mu guards wsConn, apiVersion, sessionID, resumed, stats,
+	// lastStats, load, ping, closed and draining
+	mu         sync.Mutex
+	apiVersion int
+	sessionID  string
+	resumed    bool
+	stats      *Stats
+	lastStats  time.Time
+	load       float32
+	ping       time.Duration
+	closed     bool
+	draining   bool
+	// writeMu serializes writes to wsConn
+	writeMu sync.Mutex
+
+	limit        *limiter
+	sourceLimits map[string]*limiter
+	// loadLimit backs off the loads of sources without a limit of their
+	// own
+	loadLimit *limiter
+}
+
+// RateLimit is a token bucket limit on requests
+type RateLimit struct {
+	// Rate is the amount of requests per second, unlimited if 0
+	Rate float64
+	// Burst is how many requests may be sent at once, 1 if 0
+	Burst int
+}
+
+// DefaultThrottleBackoff is how long loads back off by default, once a
+// source throttles a node
+const DefaultThrottleBackoff = 30 * time.Second
+
+// newNode validates a node config, fills in its defaults and builds the
+// node's dialer and HTTP client
+func newNode(manager *Lavalink, config NodeConfig) (*Node, error) {
+	if config.Host != "" {
+		restScheme, wsScheme := "http://", "ws://"
+		if config.Secure {
+			restScheme, wsScheme = "https://", "wss://"
+		}
+		if config.REST == "" {
+			config.REST = restScheme + config.Host
+		}
+		if config.WebSocket == "" {
+			config.WebSocket = wsScheme + config.Host
+		}
+	}
+	if config.REST == "" || config.WebSocket == "" {
+		return nil, fmt.Errorf("%w: node needs a REST and WebSocket URL, or a Host", ErrInvalidOption)
+	}
+	if config.Name == "" {
+		config.Name = config.WebSocket
+	}
+	if config.RateLimit.Rate < 0 || config.ThrottleBackoff < 0 {
+		return nil, fmt.Errorf("%w: node rate limits must not be negative", ErrInvalidOption)
+	}
+	sourceLimits := make(map[string]*limiter, len(config.SourceRateLimits))
+	for prefix, limit := range config.SourceRateLimits {
+		if prefix == "" || limit.Rate < 0 {
+			return nil, fmt.Errorf("%w: invalid rate limit for source %q", ErrInvalidOption, prefix)
+		}
+		sourceLimits[prefix] = newLimiter(limit.Rate, limit.Burst)
+	}
+
+	custom := config.TLSConfig != nil || config.Proxy != nil
+	dialer := config.Dialer
+	if dialer == nil {
+		dialer = manager.dialer
+		if custom || config.EnableCompression || config.HandshakeTimeout > 0 {
+			d := *dialer
+			if config.TLSConfig != nil {
+				d.TLSClientConfig = config.TLSConfig
+			}
+			if config.Proxy != nil {
+				d.Proxy = config.Proxy
+			}
+			if config.EnableCompression {
+				d.EnableCompression = true
+			}
+			if config.HandshakeTimeout > 0 {
+				d.HandshakeTimeout = config.HandshakeTimeout
+			}
+			dialer = &d
+		}
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = manager.httpClient
+		if custom {
+			c := *client
+			var transport *http.Transport
+			switch t := c.Transport.(type) {
+			case nil:
+				transport = http.DefaultTransport.(*http.Transport).Clone()
+			case *http.Transport:
+				transport = t.Clone()
+			default:
+				// a custom transport would lose its behavior
+				return nil, fmt.Errorf("%w: node TLSConfig and Proxy need the HTTP client's Transport to be an *http.Transport, set NodeConfig.HTTPClient instead", ErrInvalidOption)
+			}
+			if config.TLSConfig != nil {
+				transport.TLSClientConfig = config.TLSConfig
+			}
+			if config.Proxy != nil {
+				transport.Proxy = config.Proxy
+			}
+			c.Transport = transport
+			client = &c
+		}
+	}
+
+	return &Node{
+		config:       config,
+		manager:      manager,
+		dialer:       dialer,
+		httpClient:   client,
+		limit:        newLimiter(config.RateLimit.Rate, config.RateLimit.Burst),
+		sourceLimits: sourceLimits,
+		loadLimit:    newLimiter(0, 0),
+	}, nil
+}
+
+// Name returns the name of this node
+func (node *Node) Name() string {
+	return node.config.Name
+}
+
+// Stats returns the statistics this node reported last, or nil if it
+// hasn't reported any yet
+func (node *Node) Stats() *Stats {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	if node.stats == nil {
+		return nil
+	}
+	stats := *node.stats
+	return &stats
+}
+
+// Resumed returns whether the node kept its players from a previous
+// connection, see NodeConfig.ResumeKey
+func (node *Node) Resumed() bool {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	return node.resumed
+}
+
+// Draining returns whether this node is being drained
+//
+// A draining node is never picked for new players.
+func (node *Node) Draining() bool {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	return node.draining
 }
 
 func (node *Node) open() error {
+	if err := node.dial(); err != nil {
+		return &NodeError{node.config.Name, err}
+	}
+	return nil
+}
+
+// dial connects the node's websocket and starts listening to it
+func (node *Node) dial() error {
 	header := http.Header{}
 	header.Set("Authorization", node.config.Password)
 	header.Set("Num-Shards", node.manager.shards)
 	header.Set("User-Id", node.manager.userID)
+	if node.config.ResumeKey != "" {
+		header.Set("Resume-Key", node.config.ResumeKey)
+	}
 
-	ws, resp, err := websocket.DefaultDialer.Dial(node.config.WebSocket, header)
+	ws, resp, err := node.dialer.DialContext(node.manager.ctx, node.config.WebSocket, header)
 	if err != nil {
 		return err
 	}
 	vstr := resp.Header.Get("Lavalink-Api-Version")
 	v, err := strconv.Atoi(vstr)
 	if err != nil {
+		ws.Close()
 		return err
 	}
 	if v < 3 {
-		return errInvalidVersion
+		ws.Close()
+		node.manager.emit(NodeEvent{
+			Type: NodeVersionMismatch,
+			Node: node.config.Name,
+			Err:  ErrInvalidVersion,
+		})
+		return ErrInvalidVersion
 	}
 
+	node.mu.Lock()
+	if node.closed {
+		node.mu.Unlock()
+		ws.Close()
+		return ErrClosed
+	}
 	node.wsConn = ws
-	go node.listen()
+	node.apiVersion = v
+	node.resumed = resp.Header.Get("Session-Resumed") == "true"
+	node.mu.Unlock()
+
+	// configure resuming before listening, so nothing is left running
+	// if it fails
+	if node.config.ResumeKey != "" {
+		timeout := node.config.ResumeTimeout
+		if timeout == 0 {
+			timeout = DefaultResumeTimeout
+		}
+		err = node.send(message{
+			Op:      opConfigureResuming,
+			Key:     node.config.ResumeKey,
+			Timeout: int(timeout / time.Second),
+		})
+		if err != nil {
+			ws.Close()
+			return err
+		}
+	}
+
+	node.mu.Lock()
+	if node.closed {
+		node.mu.Unlock()
+		ws.Close()
+		return ErrClosed
+	}
+	node.manager.wg.Add(1)
+	node.mu.Unlock()
 
-	Log.Println("node", node.config.WebSocket, "opened")
+	done := make(chan struct{})
+	node.startKeepalive(ws, done)
+	go node.listen(ws, done)
 
+	node.logger().Info("node opened", "api_version", v)
 	return nil
 }
 
-func (node *Node) stop() {
+// close closes the node's websocket with a close frame, and stops it
+// from reconnecting
+func (node *Node) close() error {
+	node.mu.Lock()
 	// someone already stopped this
-	if node.wsConn == nil {
-		return
+	if node.closed {
+		node.mu.Unlock()
+		return nil
+	}
+	node.closed = true
+	ws := node.wsConn
+	node.mu.Unlock()
+
+	if ws == nil {
+		return nil
+	}
+
+	// listen closes the connection once Lavalink acknowledges the close
+	// frame, or the deadline passes
+	deadline := time.Now().Add(closeTimeout)
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	if err := ws.WriteControl(websocket.CloseMessage, msg, deadline); err != nil {
+		ws.Close()
+		return err
 	}
-	_ = node.wsConn.Close()
+	return ws.SetReadDeadline(deadline)
+}
+
+// send writes a message to the node's websocket
+func (node *Node) send(msg message) error {
+	return node.write(msg.Op, msg.GuildID, msg)
 }
 
-func (node *Node) listen() {
+// write writes any payload to the node's websocket, for ops which don't
+// fit in a message
+func (node *Node) write(op string, guildID string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	node.mu.Lock()
+	ws := node.wsConn
+	node.mu.Unlock()
+
+	node.logFrame("sending frame", op, guildID, data)
+
+	node.writeMu.Lock()
+	err = ws.WriteMessage(websocket.TextMessage, data)
+	node.writeMu.Unlock()
+	if err != nil {
+		return &NodeError{node.config.Name, err}
+	}
+
+	node.manager.observer().ObserveOpSent(node.config.Name, op)
+	return nil
+}
+
+func (node *Node) isClosed() bool {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	return node.closed
+}
+
+// listen reads from the node's websocket until it closes, and stops the
+// connection's keepalive by closing done
+func (node *Node) listen(ws *websocket.Conn, done chan<- struct{}) {
+	defer node.manager.wg.Done()
+	defer ws.Close()
+	stopKeepalive := sync.OnceFunc(func() { close(done) })
+	defer stopKeepalive()
+
 	for {
-		msgType, msg, err := node.wsConn.ReadMessage()
+		msgType, msg, err := ws.ReadMessage()
 		if err != nil {
-			Log.Println(err)
-			// try to reconnect
-			oerr := node.open()
-			if oerr != nil {
-				Log.Println("node", node.config.WebSocket, "failed and could not reconnect, destroying.", err, oerr)
-				node.manager.removeNode(node)
+			event := NodeEvent{
+				Type:      NodeDisconnected,
+				Node:      node.config.Name,
+				CloseCode: closeCode(err),
+			}
+			if node.isClosed() {
+				node.logger().Info("node closed")
+				node.manager.emit(event)
 				return
 			}
-			Log.Println("node", node.config.WebSocket, "reconnected")
+			node.logger().Warn("node disconnected", "err", err, "close_code", event.CloseCode)
+			event.Err = err
+			node.manager.emit(event)
+
+			ws.Close()
+			stopKeepalive()
+			node.reconnect()
 			return
 		}
-		err = node.onEvent(msgType, msg)
-		// TODO: better error handling?
+		node.extendDeadline(ws)
+		node.onEvent(msgType, msg)
+	}
+}
 
-		if err != nil {
-			Log.Println(err)
+// reconnect tries to reopen the node's websocket as the manager's
+// ReconnectPolicy says, and removes the node from its manager if all
+// attempts fail
+func (node *Node) reconnect() {
+	var err error
+	for attempt := 1; ; attempt++ {
+		delay, ok := node.manager.reconnect.Backoff(attempt)
+		if !ok {
+			break
 		}
+		if delay > 0 {
+			t := time.NewTimer(delay)
+			select {
+			case <-t.C:
+			case <-node.manager.ctx.Done():
+				t.Stop()
+				return
+			}
+		}
+
+		err = node.open()
+		if err == nil {
+			node.logger().Info("node reconnected", "attempt", attempt)
+			node.manager.observer().ObserveReconnect(node.config.Name)
+			node.manager.emit(NodeEvent{
+				Type:    NodeReconnected,
+				Node:    node.config.Name,
+				Attempt: attempt,
+			})
+			return
+		}
+		if node.isClosed() {
+			return
+		}
+		node.logger().Warn("reconnect attempt failed", "attempt", attempt, "err", err)
 	}
+
+	node.logger().Error("node failed and could not reconnect, removing it", "err", err)
+	node.manager.removeNode(node, err)
 }
 
-func (node *Node) onEvent(msgType int, msg []byte) error {
+// closeCode returns the close code of a websocket error, or 0 if the
+// connection closed without one
+func closeCode(err error) int {
+	var ce *websocket.CloseError
+	if errors.As(err, &ce) {
+		return ce.Code
+	}
+	return 0
+}
+
+// logger returns the manager's logger, annotated with this node
+func (node *Node) logger() *slog.Logger {
+	return node.manager.logger().With(logNode, node.config.Name)
+}
+
+// logFrame logs a websocket frame at debug level
+func (node *Node) logFrame(msg string, op string, guildID string, data []byte) {
+	if !node.manager.logger().Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	args := []interface{}{logOp, op}
+	if guildID != "" {
+		args = append(args, logGuild, guildID)
+	}
+	node.logger().Debug(msg, append(args, "frame", frame(data))...)
+}
+
+// frame is a websocket frame, logged with its secrets redacted
+type frame []byte
+
+// secrets are the fields of frames which aren't logged
+var secrets = map[string]bool{
+	"token":     true,
+	"sessionId": true,
+	"key":       true,
+}
+
+func (f frame) LogValue() slog.Value {
+	var v interface{}
+	if err := json.Unmarshal(f, &v); err != nil {
+		return slog.StringValue(string(f))
+	}
+	redact(v)
+	data, err := json.Marshal(v)
+	if err != nil {
+		return slog.StringValue(string(f))
+	}
+	return slog.StringValue(string(data))
+}
+
+// redact replaces the secrets of a decoded JSON value
+func redact(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if secrets[key] {
+				v[key] = "[redacted]"
+				continue
+			}
+			redact(value)
+		}
+	case []interface{}:
+		for _, value := range v {
+			redact(value)
+		}
+	}
+}
+
+func (node *Node) onEvent(msgType int, msg []byte) {
+	// plugins may send frames which aren't JSON text
 	if msgType != websocket.TextMessage {
-		return errUnknownPayload
+		node.handleRaw(msg)
+		return
 	}
 
 	m := message{}
 	err := json.Unmarshal(msg, &m)
 	if err != nil {
-		return err
+		node.handleRaw(msg)
+		return
 	}
+	node.logFrame("received frame", m.Op, m.GuildID, msg)
 
+	if err = node.handleMessage(m, msg); err != nil {
+		logger := node.logger().With(logOp, m.Op)
+		if m.GuildID != "" {
+			logger = logger.With(logGuild, m.GuildID)
+		}
+		logger.Error("couldn't handle payload", "err", err)
+	}
+}
+
+func (node *Node) handleMessage(m message, raw []byte) error {
 	switch m.Op {
 	case opPlayerUpdate:
 		player, err := node.manager.GetPlayer(m.GuildID)
 		if err != nil {
 			return err
 		}
+		player.mu.Lock()
 		player.time = m.State.Time
 		player.position = m.State.Position
+		player.mu.Unlock()
 	case opEvent:
+		obs := node.manager.observer()
+		obs.ObserveEvent(node.config.Name, m.Type)
+		if m.Type == eventTrackException && m.Exception != nil {
+			obs.ObserveTrackException(node.config.Name, m.Exception.Severity)
+		}
+
+		// plugin events may concern guilds without a player
+		if !isTrackEvent(m.Type) {
+			handler := node.manager.registry.event(m.Type)
+			if handler == nil {
+				node.handleRaw(raw)
+				return nil
+			}
+			player, err := node.manager.GetPlayer(m.GuildID)
+			if err != nil {
+				node.handleRaw(raw)
+				return nil
+			}
+			return handler(player, raw)
+		}
+
 		player, err := node.manager.GetPlayer(m.GuildID)
 		if err != nil {
 			return err
@@ -120,24 +650,75 @@ func (node *Node) onEvent(msgType int, msg []byte) error {
 
 		switch m.Type {
 		case eventTrackEnd:
-			player.track = ""
+			// a replaced track ends after its replacement started, which
+			// may be the same track played again
+			player.mu.Lock()
+			if m.Reason != EndReasonReplaced && m.Track == player.track {
+				player.track = ""
+			}
+			player.mu.Unlock()
+			player.history.end(m.Track, m.Reason, time.Now())
 			err = player.handler.OnTrackEnd(player, m.Track, m.Reason)
+			// the handler had nothing else to play
+			if MayStartNext(m.Reason) && player.Track() == "" && player.queue.Len() == 0 {
+				player.autoplay(m.Track)
+			}
 		case eventTrackException:
-			err = player.handler.OnTrackException(player, m.Track, m.Reason)
+			reason := m.Error
+			if reason == "" && m.Exception != nil {
+				reason = m.Exception.Message
+			}
+			err = player.handler.OnTrackException(player, m.Track, reason)
 		case eventTrackStuck:
-			err = player.handler.OnTrackStuck(player, m.Track, m.ThresholdMs)
+			if h, ok := player.handler.(TrackStuckHandler); ok {
+				err = h.OnTrackStuckDuration(player, m.Track, Millis(m.ThresholdMs))
+			} else {
+				err = player.handler.OnTrackStuck(player, m.Track, m.ThresholdMs)
+			}
 		}
 
 		return err
+	case opReady:
+		node.mu.Lock()
+		node.sessionID = m.SessionID
+		node.resumed = m.Resumed
+		node.mu.Unlock()
+		node.logger().Info("node ready", "session", m.SessionID, "resumed", m.Resumed)
 	case opStats:
-		node.load = m.StatCPU.Load
+		if m.Stats == nil {
+			return ErrUnknownPayload
+		}
+		stats := *m.Stats
+		node.mu.Lock()
+		node.stats = &stats
+		node.lastStats = time.Now()
+		node.load = stats.CPU.LavalinkLoad
+		node.mu.Unlock()
+		node.manager.observer().ObserveStats(node.config.Name, stats)
 	default:
-		return errUnknownPayload
+		if handler := node.manager.registry.op(m.Op); handler != nil {
+			return handler(node, raw)
+		}
+		node.handleRaw(raw)
 	}
 
 	return nil
 }
 
+// isTrackEvent reports whether gavalink handles an event type itself
+func isTrackEvent(eventType string) bool {
+	return eventType == eventTrackEnd || eventType == eventTrackException || eventType == eventTrackStuck
+}
+
+// handleRaw passes a payload nothing else handles to the raw handler
+func (node *Node) handleRaw(raw []byte) {
+	if handler := node.manager.registry.rawHandler(); handler != nil {
+		handler(node, raw)
+		return
+	}
+	node.logger().Debug("dropped unhandled payload", "frame", frame(raw))
+}
+
 // CreatePlayer creates an audio player on this node
 func (node *Node) CreatePlayer(guildID string, sessionID string, event VoiceServerUpdate, handler EventHandler) (*Player, error) {
 	msg := message{
@@ -146,27 +727,30 @@ func (node *Node) CreatePlayer(guildID string, sessionID string, event VoiceServ
 		SessionID: sessionID,
 		Event:     &event,
 	}
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return nil, err
-	}
-	err = node.wsConn.WriteMessage(websocket.TextMessage, data)
+	err := node.send(msg)
 	if err != nil {
 		return nil, err
 	}
 	player := &Player{
-		guildID: guildID,
-		manager: node.manager,
-		node:    node,
-		handler: handler,
-		vol:     100,
+		guildID:   guildID,
+		sessionID: sessionID,
+		server:    event,
+		manager:   node.manager,
+		node:      node,
+		handler:   handler,
+		vol:       100,
 	}
+	player.history.size = node.manager.historySize
+	node.manager.mu.Lock()
 	node.manager.players[guildID] = player
+	node.manager.mu.Unlock()
 	return player, nil
 }
 
 // LoadTracks queries lavalink to return a Tracks object
 //
+// If Lavalink fails to load the query, a *LoadFailedError is returned.
+//
 // query should be a valid Lavaplayer query, including but not limited to:
 // - A direct media URI
 // - A direct Youtube /watch URI
@@ -174,25 +758,171 @@ func (node *Node) CreatePlayer(guildID string, sessionID string, event VoiceServ
 //
 // See the Lavaplayer Source Code for all valid options.
 func (node *Node) LoadTracks(query string) (*Tracks, error) {
-	url := fmt.Sprintf("%s/loadtracks?identifier=%s", node.config.REST, query)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	return node.LoadTracksContext(context.Background(), query)
+}
+
+// LoadTracksContext is like LoadTracks, with a context for cancellation
+// and tracing
+func (node *Node) LoadTracksContext(ctx context.Context, query string) (tracks *Tracks, err error) {
+	ctx, span := node.manager.tracer().Start(ctx, "gavalink.LoadTracks", Attribute{AttrNode, node.config.Name})
+	defer func() { span.End(err) }()
+
+	source, limit := node.sourceLimit(query)
+	if limit == nil {
+		// without a limit of their own, throttled sources back off every
+		// such load, but not the node's other routes
+		limit = node.loadLimit
+	}
+	if err = node.wait(ctx, source, limit); err != nil {
+		return nil, &NodeError{node.config.Name, err}
+	}
+
+	path := "/loadtracks?identifier=" + url.QueryEscape(query)
+	tracks = new(Tracks)
+	err = node.rest(ctx, "loadtracks", http.MethodGet, path, nil, tracks)
+	if err == nil && tracks.Type == LoadFailed {
+		err = &LoadFailedError{query, tracks.Exception}
+	}
 	if err != nil {
+		if throttled(err) {
+			backoff := node.config.ThrottleBackoff
+			if backoff == 0 {
+				backoff = DefaultThrottleBackoff
+			}
+			backoff = limit.throttle(backoff)
+			node.logger().Warn("source throttled, backing off", "source", source, "backoff", backoff, "err", err)
+		}
 		return nil, err
 	}
-	req.Header.Set("Authorization", node.config.Password)
+	limit.succeed()
+	span.SetAttributes(Attribute{AttrLoadType, tracks.Type})
+	return tracks, nil
+}
+
+// sourceLimit returns the limiter of the longest source prefix query
+// starts with, if any
+func (node *Node) sourceLimit(query string) (string, *limiter) {
+	var source string
+	var limit *limiter
+	for prefix, l := range node.sourceLimits {
+		if len(prefix) > len(source) && strings.HasPrefix(query, prefix) {
+			source, limit = prefix, l
+		}
+	}
+	return source, limit
+}
+
+// wait waits for a rate limiter, and reports the time waited to the
+// manager's observer
+func (node *Node) wait(ctx context.Context, source string, limit *limiter) error {
+	waited, err := limit.wait(ctx)
+	if waited > 0 {
+		node.manager.observer().ObserveRateLimit(node.config.Name, source, waited)
+	}
+	return err
+}
+
+// throttled reports whether an error means a source rate limited the
+// node
+func throttled(err error) bool {
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.code == http.StatusTooManyRequests
+	}
+
+	var loadFailed *LoadFailedError
+	if !errors.As(err, &loadFailed) || loadFailed.Exception == nil {
+		return false
+	}
+	msg := strings.ToLower(loadFailed.Exception.Message + " " + loadFailed.Exception.Cause)
+	return strings.Contains(msg, "429") ||
+		strings.Contains(msg, "too many requests") ||
+		strings.Contains(msg, "sign in to confirm")
+}
+
+// statusError is returned by REST requests Lavalink answered with a
+// non-2xx status
+type statusError struct {
+	code   int
+	status string
+	body   []byte
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("lavalink returned %s: %s", e.status, e.body)
+}
+
+// DecodeTracks asks lavalink to decode base64 Lavaplayer tracks
+//
+// Unlike DecodeString, this also decodes tracks whose format gavalink
+// doesn't know.
+func (node *Node) DecodeTracks(ctx context.Context, tracks ...string) (decoded []Track, err error) {
+	ctx, span := node.manager.tracer().Start(ctx, "gavalink.DecodeTracks", Attribute{AttrNode, node.config.Name})
+	defer func() { span.End(err) }()
 
-	resp, err := http.DefaultClient.Do(req)
+	err = node.rest(ctx, "decodetracks", http.MethodPost, "/decodetracks", tracks, &decoded)
 	if err != nil {
 		return nil, err
 	}
-	data, err := ioutil.ReadAll(resp.Body)
+	return decoded, nil
+}
+
+// rest sends a REST request to the node, encoding body and decoding the
+// response into out as JSON, if they aren't nil
+//
+// If out is a *string, it is set to the raw response instead.
+//
+// route names the request to the manager's observer.
+func (node *Node) rest(ctx context.Context, route string, method string, path string, body interface{}, out interface{}) (err error) {
+	if err = node.wait(ctx, "", node.limit); err != nil {
+		return &NodeError{node.config.Name, err}
+	}
+
+	// the latency leaves out the time waited for rate limits
+	start := time.Now()
+	defer func() {
+		if err != nil {
+			err = &NodeError{node.config.Name, err}
+		}
+		node.manager.observer().ObserveREST(node.config.Name, route, time.Since(start), err)
+	}()
+
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, node.config.REST+path, r)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	req.Header.Set("Authorization", node.config.Password)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
-	tracks := new(Tracks)
-	err = json.Unmarshal(data, &tracks)
+
+	resp, err := node.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return tracks, nil
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &statusError{resp.StatusCode, resp.Status, data}
+	}
+	switch out := out.(type) {
+	case nil:
+		return nil
+	case *string:
+		*out = string(data)
+		return nil
+	}
+	return json.Unmarshal(data, out)
 }
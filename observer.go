@@ -0,0 +1,66 @@
+package gavalink
+
+import (
+	"time"
+)
+
+// Observer receives measurements of a manager's nodes, e.g. to export
+// them as metrics
+//
+// Methods are called synchronously from gavalink's goroutines and should
+// return quickly. See the metrics package for an implementation.
+type Observer interface {
+	// ObserveStats is called with every stats payload a node sends
+	ObserveStats(node string, stats Stats)
+	// ObserveReconnect is called when a node has reconnected
+	ObserveReconnect(node string)
+	// ObserveREST is called when a REST request to a node completes;
+	// latency leaves out the time waited for rate limits
+	ObserveREST(node string, route string, latency time.Duration, err error)
+	// ObserveRateLimit is called when a REST request to a node waited
+	// for a rate limit; source is the source prefix whose limit it
+	// waited for, or empty for the node's limit
+	ObserveRateLimit(node string, source string, wait time.Duration)
+	// ObserveOpSent is called when an op has been sent to a node
+	ObserveOpSent(node string, op string)
+	// ObserveEvent is called when a node sends a player event
+	ObserveEvent(node string, event string)
+	// ObserveTrackException is called when a track throws an exception
+	ObserveTrackException(node string, severity string)
+}
+
+// NodeRemovalObserver is told when a node is removed from the manager
+//
+// An Observer which also implements NodeRemovalObserver can drop what it
+// recorded about the node.
+type NodeRemovalObserver interface {
+	ObserveNodeRemoved(node string)
+}
+
+// nopObserver is used by managers without an observer
+type nopObserver struct{}
+
+func (nopObserver) ObserveStats(string, Stats)                       {}
+func (nopObserver) ObserveReconnect(string)                          {}
+func (nopObserver) ObserveREST(string, string, time.Duration, error) {}
+func (nopObserver) ObserveRateLimit(string, string, time.Duration)   {}
+func (nopObserver) ObserveOpSent(string, string)                     {}
+func (nopObserver) ObserveEvent(string, string)                      {}
+func (nopObserver) ObserveTrackException(string, string)             {}
+
+// SetObserver sets the observer receiving this manager's measurements
+func (lavalink *Lavalink) SetObserver(observer Observer) {
+	lavalink.mu.Lock()
+	lavalink.obs = observer
+	lavalink.mu.Unlock()
+}
+
+func (lavalink *Lavalink) observer() Observer {
+	lavalink.mu.RLock()
+	defer lavalink.mu.RUnlock()
+
+	if lavalink.obs == nil {
+		return nopObserver{}
+	}
+	return lavalink.obs
+}
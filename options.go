@@ -0,0 +1,201 @@
+package gavalink
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/websocket"
+)
+
+// Option configures a Lavalink manager created with New
+type Option func(lavalink *Lavalink) error
+
+// New creates a new Lavalink manager for the bot with the given user ID
+//
+// Options are validated eagerly; New fails if any of them is invalid.
+// Without options, the manager runs with a single shard and writes to
+// Log.
+func New(userID string, options ...Option) (*Lavalink, error) {
+	if _, err := strconv.ParseUint(userID, 10, 64); err != nil {
+		return nil, fmt.Errorf("%w: user ID %q isn't a snowflake", ErrInvalidOption, userID)
+	}
+
+	lavalink := newLavalink("1", userID)
+	for _, option := range options {
+		if err := option(lavalink); err != nil {
+			lavalink.cancel()
+			return nil, err
+		}
+	}
+
+	if lavalink.events != nil {
+		lavalink.dispatchDone = make(chan struct{})
+		go lavalink.dispatch()
+	}
+	return lavalink, nil
+}
+
+// NewLavalink creates a new Lavalink manager
+//
+// NewLavalink doesn't validate its arguments; New should be preferred.
+func NewLavalink(shards string, userID string) *Lavalink {
+	return newLavalink(shards, userID)
+}
+
+func newLavalink(shards string, userID string) *Lavalink {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Lavalink{
+		shards:      shards,
+		userID:      userID,
+		players:     make(map[string]*Player),
+		ctx:         ctx,
+		cancel:      cancel,
+		httpClient:  http.DefaultClient,
+		dialer:      websocket.DefaultDialer,
+		balancer:    PenaltyBalancer{},
+		reconnect:   DefaultReconnectPolicy,
+		registry:    newRegistry(),
+		historySize: DefaultHistorySize,
+		voice:       make(map[string]*voiceConn),
+		joins:       make(map[string]*voiceJoin),
+	}
+}
+
+// WithShardCount sets the amount of shards the bot runs
+func WithShardCount(shards int) Option {
+	return func(lavalink *Lavalink) error {
+		if shards < 1 {
+			return fmt.Errorf("%w: shard count must be positive, got %d", ErrInvalidOption, shards)
+		}
+		lavalink.shards = strconv.Itoa(shards)
+		return nil
+	}
+}
+
+// WithLogger sets the logger the manager writes to
+func WithLogger(logger *slog.Logger) Option {
+	return func(lavalink *Lavalink) error {
+		if logger == nil {
+			return fmt.Errorf("%w: nil logger", ErrInvalidOption)
+		}
+		lavalink.log = logger
+		return nil
+	}
+}
+
+// WithHTTPClient sets the client REST requests to nodes are sent with
+func WithHTTPClient(client *http.Client) Option {
+	return func(lavalink *Lavalink) error {
+		if client == nil {
+			return fmt.Errorf("%w: nil HTTP client", ErrInvalidOption)
+		}
+		lavalink.httpClient = client
+		return nil
+	}
+}
+
+// WithDialer sets the dialer node websockets are opened with
+func WithDialer(dialer *websocket.Dialer) Option {
+	return func(lavalink *Lavalink) error {
+		if dialer == nil {
+			return fmt.Errorf("%w: nil dialer", ErrInvalidOption)
+		}
+		lavalink.dialer = dialer
+		return nil
+	}
+}
+
+// WithLoadBalancer sets the balancer picking nodes for new players
+func WithLoadBalancer(balancer LoadBalancer) Option {
+	return func(lavalink *Lavalink) error {
+		if balancer == nil {
+			return fmt.Errorf("%w: nil load balancer", ErrInvalidOption)
+		}
+		lavalink.balancer = balancer
+		return nil
+	}
+}
+
+// WithReconnectPolicy sets how nodes reconnect after a disconnect
+func WithReconnectPolicy(policy ReconnectPolicy) Option {
+	return func(lavalink *Lavalink) error {
+		if policy == nil {
+			return fmt.Errorf("%w: nil reconnect policy", ErrInvalidOption)
+		}
+		lavalink.reconnect = policy
+		return nil
+	}
+}
+
+// WithTrackCache caches up to size decoded tracks
+//
+// The cache is used by DecodeTrack and Player.TrackInfo. A size of 0
+// disables it.
+func WithTrackCache(size int) Option {
+	return func(lavalink *Lavalink) error {
+		if size < 0 {
+			return fmt.Errorf("%w: track cache size must not be negative, got %d", ErrInvalidOption, size)
+		}
+		lavalink.cache = newTrackCache(size)
+		return nil
+	}
+}
+
+// WithHistorySize sets how many tracks each player's history holds
+//
+// A size of 0 disables histories.
+func WithHistorySize(size int) Option {
+	return func(lavalink *Lavalink) error {
+		if size < 0 {
+			return fmt.Errorf("%w: history size must not be negative, got %d", ErrInvalidOption, size)
+		}
+		lavalink.historySize = size
+		return nil
+	}
+}
+
+// WithEventBuffer delivers node events asynchronously through a buffer
+// of the given size
+//
+// By default, node events are delivered synchronously from the node's
+// goroutine. With a buffer, a slow NodeEventHandler only holds up nodes
+// once the buffer is full. A size of 0 keeps delivery synchronous.
+func WithEventBuffer(size int) Option {
+	return func(lavalink *Lavalink) error {
+		if size < 0 {
+			return fmt.Errorf("%w: event buffer size must not be negative, got %d", ErrInvalidOption, size)
+		}
+		lavalink.events = nil
+		if size > 0 {
+			lavalink.events = make(chan NodeEvent, size)
+		}
+		return nil
+	}
+}
+
+// WithNodeEventHandler sets the handler receiving node lifecycle events
+func WithNodeEventHandler(handler NodeEventHandler) Option {
+	return func(lavalink *Lavalink) error {
+		lavalink.nodeHandler = handler
+		return nil
+	}
+}
+
+// WithObserver sets the observer receiving the manager's measurements
+func WithObserver(observer Observer) Option {
+	return func(lavalink *Lavalink) error {
+		lavalink.obs = observer
+		return nil
+	}
+}
+
+// WithTracer sets the tracer the manager starts spans with
+func WithTracer(tracer Tracer) Option {
+	return func(lavalink *Lavalink) error {
+		lavalink.trc = tracer
+		return nil
+	}
+}
@@ -0,0 +1,176 @@
+package gavalink
+
+import (
+	"context"
+	"time"
+)
+
+// PlayOptions configures how PlayWithOptions plays a track
+type PlayOptions struct {
+	// Start is the position to start playing at, the start of the track
+	// if 0
+	Start time.Duration
+	// End is the position to stop playing at, the end of the track if 0
+	End time.Duration
+	// NoReplace doesn't replace a track the player is playing, playing
+	// fails with ErrAlreadyPlaying instead
+	NoReplace bool
+	// Paused starts the player paused
+	Paused bool
+	// Volume sets the player's volume, if not nil; a track fading in is
+	// faded to it
+	Volume *int
+	// Filters sets the player's filters, if not nil
+	Filters *Filters
+}
+
+// PlayWithOptions plays a track with the given options
+//
+// Start and End are validated against the decoded track: starting a
+// track which isn't seekable, or a position outside of the track, fails
+// with a *PositionError. Tracks gavalink can't decode aren't validated.
+//
+// The track is faded in and out as set by SetFadeOptions.
+func (player *Player) PlayWithOptions(ctx context.Context, track string, options PlayOptions) error {
+	if options.NoReplace && player.Track() != "" {
+		return ErrAlreadyPlaying
+	}
+	if options.Volume != nil && (*options.Volume < 0 || *options.Volume > 1000) {
+		return ErrVolumeOutOfRange
+	}
+	if err := player.validate(track, options.Start, options.End); err != nil {
+		return err
+	}
+
+	fade := player.FadeOptions()
+	fadeIn := fade.In > 0 && !options.Paused
+	var target *int
+	if fadeIn {
+		// start silent, the fade brings the volume back
+		if err := player.sendVolume(ctx, 0); err != nil {
+			return err
+		}
+		if !fade.Filters {
+			// the volume is faded to, not played at right away
+			target, options.Volume = options.Volume, nil
+		}
+	}
+
+	if err := player.playWith(ctx, track, options); err != nil {
+		return err
+	}
+	if target != nil {
+		player.mu.Lock()
+		player.vol = *target
+		player.mu.Unlock()
+	}
+	play := player.currentPlay()
+	player.record(track)
+
+	if options.Filters != nil {
+		if err := player.SetFilters(ctx, options.Filters); err != nil {
+			return err
+		}
+	}
+	if fadeIn {
+		player.fadeIn(fade.In)
+	}
+	if fade.Transition > 0 {
+		player.watchTransition(track, play, fade.Transition)
+	}
+	return nil
+}
+
+// validate checks that a track can be played from start until end; 0
+// times are left out
+func (player *Player) validate(track string, start time.Duration, end time.Duration) error {
+	if start == 0 && end == 0 {
+		return nil
+	}
+	info, err := player.manager.DecodeTrack(track)
+	if err != nil {
+		player.logger().Debug("not validating undecodable track", "err", err)
+		return nil
+	}
+
+	if start != 0 {
+		if err := validatePosition(info, start, true); err != nil {
+			return err
+		}
+	}
+	if end != 0 {
+		if err := validatePosition(info, end, false); err != nil {
+			return err
+		}
+		if end <= start {
+			return &PositionError{end, info.Duration(), ErrPositionOutOfRange}
+		}
+	}
+	return nil
+}
+
+// validateSeek checks that a track can be seeked to position
+func (player *Player) validateSeek(track string, position time.Duration) error {
+	info, err := player.manager.DecodeTrack(track)
+	if err != nil {
+		player.logger().Debug("not validating undecodable track", "err", err)
+		return nil
+	}
+	return validatePosition(info, position, true)
+}
+
+func validatePosition(info *TrackInfo, position time.Duration, seek bool) error {
+	length := info.Duration()
+	if seek && !info.Seekable {
+		return &PositionError{position, length, ErrNotSeekable}
+	}
+	if position < 0 || (length != InfiniteDuration && position > length) {
+		return &PositionError{position, length, ErrPositionOutOfRange}
+	}
+	return nil
+}
+
+// SeekBy seeks the player forward by delta, or back if delta is
+// negative
+//
+// The position is clamped to the track, so seeking past either end
+// seeks to it.
+func (player *Player) SeekBy(ctx context.Context, delta time.Duration) error {
+	track := player.Track()
+	if track == "" {
+		return ErrNoTrack
+	}
+
+	position := player.Elapsed() + delta
+	if position < 0 {
+		position = 0
+	}
+	if info, err := player.manager.DecodeTrack(track); err == nil {
+		if length := info.Duration(); length != InfiniteDuration && position > length {
+			position = length
+		}
+	}
+	return player.SeekTo(ctx, position)
+}
+
+// Restart seeks the player back to the start of its track
+func (player *Player) Restart(ctx context.Context) error {
+	return player.SeekTo(ctx, 0)
+}
+
+// Replay plays the player's last track again from its start, even if
+// it ended
+//
+// Unlike Restart, Replay plays the track again, so it works for streams
+// too. Replay returns ErrNoHistory if no track was played.
+func (player *Player) Replay(ctx context.Context) error {
+	track := player.Track()
+	if track == "" {
+		entries := player.history.Entries()
+		if len(entries) == 0 {
+			return ErrNoHistory
+		}
+		track = entries[len(entries)-1].Track
+	}
+	return player.PlayContext(ctx, track)
+}
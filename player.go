@@ -1,23 +1,45 @@
 package gavalink
 
 import (
-	"encoding/json"
+	"context"
+	"log/slog"
 	"strconv"
-
-	"github.com/gorilla/websocket"
+	"sync"
+	"time"
 )
 
 // Player is a Lavalink player
+//
+// A Player is safe for concurrent use.
 type Player struct {
-	guildID  string
-	time     int
-	position int
-	paused   bool
-	vol      int
-	track    string
-	manager  *Lavalink
-	node     *Node
-	handler  EventHandler
+	guildID string
+	queue   Queue
+	history History
+	manager *Lavalink
+	handler EventHandler
+
+	// mu guards the state below, it's never held while sending to the
+	// node
+	mu        sync.Mutex
+	node      *Node
+	sessionID string
+	server    VoiceServerUpdate
+	time      int64
+	position  int
+	paused    bool
+	vol       int
+	track     string
+	filters   *Filters
+
+	// plays counts the tracks played, so background work can tell the
+	// track it was started for has been replaced
+	plays       uint64
+	fadeOptions FadeOptions
+	faded       bool
+	fadeLevel   int
+	fadeCancel  context.CancelFunc
+
+	autoplayProvider AutoplayProvider
 }
 
 // GuildID returns this player's Guild ID
@@ -25,95 +47,250 @@ func (player *Player) GuildID() string {
 	return player.guildID
 }
 
+// logger returns the manager's logger, annotated with this player
+func (player *Player) logger() *slog.Logger {
+	return player.Node().logger().With(logGuild, player.guildID)
+}
+
+// send sends a message to the player's node, traced as a player op
+func (player *Player) send(ctx context.Context, msg message) error {
+	return player.write(ctx, msg.Op, msg)
+}
+
+// write writes any payload to the player's node, traced as a player op
+func (player *Player) write(ctx context.Context, op string, payload interface{}) (err error) {
+	node := player.Node()
+	_, span := player.manager.tracer().Start(ctx, "gavalink.player."+op,
+		Attribute{AttrOp, op},
+		Attribute{AttrGuild, player.guildID},
+		Attribute{AttrNode, node.Name()},
+	)
+	defer func() { span.End(err) }()
+
+	return node.write(op, player.guildID, payload)
+}
+
+// Node returns the node this player is playing on
+func (player *Player) Node() *Node {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	return player.node
+}
+
+// voiceServer returns the voice server this player was forwarded
+func (player *Player) voiceServer() VoiceServerUpdate {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	return player.server
+}
+
 // Play will play the given track completely
 func (player *Player) Play(track string) error {
-	return player.PlayAt(track, 0, 0)
+	return player.PlayContext(context.Background(), track)
+}
+
+// PlayContext is like Play, with a context for tracing
+func (player *Player) PlayContext(ctx context.Context, track string) error {
+	return player.PlayAtContext(ctx, track, 0, 0)
 }
 
 // PlayAt will play the given track at the specified start and end times
 //
 // Setting a time to 0 will omit it.
 func (player *Player) PlayAt(track string, startTime int, endTime int) error {
-	player.paused = false
-	player.track = track
+	return player.PlayAtContext(context.Background(), track, startTime, endTime)
+}
 
-	start := strconv.Itoa(startTime)
-	end := strconv.Itoa(endTime)
+// PlayAtContext is like PlayAt, with a context for tracing
+//
+// The track is faded in and out as set by SetFadeOptions. Times are
+// validated like PlayWithOptions does.
+func (player *Player) PlayAtContext(ctx context.Context, track string, startTime int, endTime int) error {
+	return player.PlayWithOptions(ctx, track, PlayOptions{
+		Start: Millis(startTime),
+		End:   Millis(endTime),
+	})
+}
+
+// play plays a track, without fading or validating it
+func (player *Player) play(ctx context.Context, track string, startTime int, endTime int) error {
+	return player.playWith(ctx, track, PlayOptions{
+		Start: Millis(startTime),
+		End:   Millis(endTime),
+	})
+}
 
+// playWith sends a play op with the given options
+func (player *Player) playWith(ctx context.Context, track string, options PlayOptions) error {
 	msg := message{
 		Op:        opPlay,
 		GuildID:   player.guildID,
 		Track:     track,
-		StartTime: start,
-		EndTime:   end,
+		StartTime: strconv.Itoa(toMillis(options.Start)),
+		EndTime:   strconv.Itoa(toMillis(options.End)),
+		NoReplace: options.NoReplace,
+		Volume:    options.Volume,
 	}
-	data, err := json.Marshal(msg)
-	if err != nil {
+	if options.Paused {
+		msg.Pause = &options.Paused
+	}
+	if err := player.send(ctx, msg); err != nil {
 		return err
 	}
-	err = player.node.wsConn.WriteMessage(websocket.TextMessage, data)
-	return err
+
+	player.mu.Lock()
+	player.paused = options.Paused
+	player.track = track
+	player.plays++
+	// the position is interpolated from the start until the node reports
+	// it
+	player.position = toMillis(options.Start)
+	player.time = 0
+	if !options.Paused {
+		player.time = time.Now().UnixMilli()
+	}
+	if options.Volume != nil {
+		player.vol = *options.Volume
+	}
+	player.mu.Unlock()
+	return nil
 }
 
 // Track returns the player's current track
 func (player *Player) Track() string {
+	player.mu.Lock()
+	defer player.mu.Unlock()
 	return player.track
 }
 
+// playing reports whether the player still plays track, as the given
+// play
+func (player *Player) playing(play uint64, track string) bool {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	return player.plays == play && player.track == track
+}
+
+// currentPlay returns the number of the play of the current track
+func (player *Player) currentPlay() uint64 {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	return player.plays
+}
+
+// TrackInfo decodes the player's current track
+//
+// TrackInfo returns nil if the player isn't playing a track.
+func (player *Player) TrackInfo() (*TrackInfo, error) {
+	track := player.Track()
+	if track == "" {
+		return nil, nil
+	}
+	return player.manager.DecodeTrack(track)
+}
+
 // Stop will stop the currently playing track
 func (player *Player) Stop() error {
+	return player.StopContext(context.Background())
+}
+
+// StopContext is like Stop, with a context for tracing
+//
+// If SetFadeOptions set a fade out, StopContext blocks until the track
+// has faded out.
+func (player *Player) StopContext(ctx context.Context) error {
+	player.interrupt()
+
+	player.mu.Lock()
+	out := player.fadeOptions.Out
+	fadeOut := out > 0 && player.track != "" && !player.paused
+	player.mu.Unlock()
+	if fadeOut {
+		fctx, cancel := player.startFade(ctx)
+		err := player.fade(fctx, 0, out, false)
+		cancel()
+		if err != nil && ctx.Err() != nil {
+			return err
+		}
+	}
+
+	player.mu.Lock()
 	player.track = ""
+	player.mu.Unlock()
 	msg := message{
 		Op:      opStop,
 		GuildID: player.guildID,
 	}
-	data, err := json.Marshal(msg)
-	if err != nil {
+	if err := player.send(ctx, msg); err != nil {
 		return err
 	}
-	err = player.node.wsConn.WriteMessage(websocket.TextMessage, data)
-	return err
+	if fadeOut {
+		return player.restoreVolume(ctx)
+	}
+	return nil
 }
 
 // Pause will pause or resume the player, depending on the pause parameter
 func (player *Player) Pause(pause bool) error {
+	return player.PauseContext(context.Background(), pause)
+}
+
+// PauseContext is like Pause, with a context for tracing
+func (player *Player) PauseContext(ctx context.Context, pause bool) error {
+	player.mu.Lock()
 	player.paused = pause
+	player.mu.Unlock()
 
 	msg := message{
 		Op:      opPause,
 		GuildID: player.guildID,
 		Pause:   &pause,
 	}
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return err
-	}
-	err = player.node.wsConn.WriteMessage(websocket.TextMessage, data)
-	return err
+	return player.send(ctx, msg)
 }
 
 // Paused returns whether or not the player is currently paused
 func (player *Player) Paused() bool {
+	player.mu.Lock()
+	defer player.mu.Unlock()
 	return player.paused
 }
 
 // Seek will seek the player to the speicifed position, in millis
+//
+// SeekTo takes the position as a time.Duration.
 func (player *Player) Seek(position int) error {
+	return player.SeekContext(context.Background(), position)
+}
+
+// SeekContext is like Seek, with a context for tracing
+//
+// Seeking a track which isn't seekable, or past its end, fails with a
+// *PositionError.
+func (player *Player) SeekContext(ctx context.Context, position int) error {
+	track := player.Track()
+	if track == "" {
+		return ErrNoTrack
+	}
+	if err := player.validateSeek(track, Millis(position)); err != nil {
+		return err
+	}
+
 	msg := message{
 		Op:       opSeek,
 		GuildID:  player.guildID,
 		Position: &position,
 	}
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return err
-	}
-	err = player.node.wsConn.WriteMessage(websocket.TextMessage, data)
-	return err
+	return player.send(ctx, msg)
 }
 
-// Position returns the player's position, as reported by Lavalink
+// Position returns the player's position, as reported by Lavalink, in
+// millis
+//
+// Elapsed interpolates the position since Lavalink's last report.
 func (player *Player) Position() int {
+	player.mu.Lock()
+	defer player.mu.Unlock()
 	return player.position
 }
 
@@ -121,27 +298,31 @@ func (player *Player) Position() int {
 //
 // volume must be within [0, 1000]
 func (player *Player) Volume(volume int) error {
+	return player.VolumeContext(context.Background(), volume)
+}
+
+// VolumeContext is like Volume, with a context for tracing
+func (player *Player) VolumeContext(ctx context.Context, volume int) error {
 	if volume < 0 || volume > 1000 {
-		return errVolumeOutOfRange
+		return ErrVolumeOutOfRange
 	}
 
+	player.mu.Lock()
 	player.vol = volume
+	player.mu.Unlock()
 
 	msg := message{
 		Op:      opVolume,
 		GuildID: player.guildID,
 		Volume:  &volume,
 	}
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return err
-	}
-	err = player.node.wsConn.WriteMessage(websocket.TextMessage, data)
-	return err
+	return player.send(ctx, msg)
 }
 
 // GetVolume gets the player's volume level
 func (player *Player) GetVolume() int {
+	player.mu.Lock()
+	defer player.mu.Unlock()
 	return player.vol
 }
 
@@ -154,34 +335,106 @@ func (player *Player) GetVolume() int {
 // To move a player to a new Node, first player.Destroy() it, and then
 // create a new player on the new node.
 func (player *Player) Forward(sessionID string, event VoiceServerUpdate) error {
+	return player.ForwardContext(context.Background(), sessionID, event)
+}
+
+// ForwardContext is like Forward, with a context for tracing
+func (player *Player) ForwardContext(ctx context.Context, sessionID string, event VoiceServerUpdate) error {
+	player.mu.Lock()
+	player.sessionID = sessionID
+	player.server = event
+	player.mu.Unlock()
+
 	msg := message{
 		Op:        opVoiceUpdate,
 		GuildID:   player.guildID,
 		SessionID: sessionID,
 		Event:     &event,
 	}
-	data, err := json.Marshal(msg)
-	if err != nil {
+	return player.send(ctx, msg)
+}
+
+// move destroys this player on its node and recreates it on another,
+// resuming the current track at its last known position
+func (player *Player) move(node *Node) error {
+	msg := message{
+		Op:      opDestroy,
+		GuildID: player.guildID,
+	}
+	// the old node may already be gone, keep going either way
+	if err := player.Node().send(msg); err != nil {
+		player.logger().Warn("couldn't destroy moved player", "err", err)
+	}
+
+	position := player.interpolatedPosition()
+	player.mu.Lock()
+	player.node = node
+	sessionID, server := player.sessionID, player.server
+	track, paused, vol, filters := player.track, player.paused, player.vol, player.filters
+	transition := player.fadeOptions.Transition
+	player.mu.Unlock()
+
+	if err := player.Forward(sessionID, server); err != nil {
 		return err
 	}
-	err = player.node.wsConn.WriteMessage(websocket.TextMessage, data)
-	return err
+
+	if track == "" {
+		return nil
+	}
+	player.interrupt()
+	if err := player.play(context.Background(), track, position, 0); err != nil {
+		return err
+	}
+	if transition > 0 {
+		player.watchTransition(track, player.currentPlay(), transition)
+	}
+	if paused {
+		if err := player.Pause(true); err != nil {
+			return err
+		}
+	}
+	if vol != 100 {
+		if err := player.Volume(vol); err != nil {
+			return err
+		}
+	}
+	if filters != nil {
+		return player.SetFilters(context.Background(), filters)
+	}
+	return nil
+}
+
+// interpolatedPosition returns the player's position, extrapolated from
+// Lavalink's last update if the track kept playing since
+func (player *Player) interpolatedPosition() int {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	position := player.position
+	if !player.paused && player.time > 0 {
+		position += int(time.Now().UnixMilli() - player.time)
+	}
+	return position
 }
 
 // Destroy will destroy this player
 func (player *Player) Destroy() error {
+	return player.DestroyContext(context.Background())
+}
+
+// DestroyContext is like Destroy, with a context for tracing
+func (player *Player) DestroyContext(ctx context.Context) error {
+	player.interrupt()
+
 	msg := message{
 		Op:      opDestroy,
 		GuildID: player.guildID,
 	}
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return err
-	}
-	err = player.node.wsConn.WriteMessage(websocket.TextMessage, data)
+	err := player.send(ctx, msg)
 	if err != nil {
 		return err
 	}
+	player.manager.mu.Lock()
 	delete(player.manager.players, player.guildID)
+	player.manager.mu.Unlock()
 	return nil
 }
@@ -0,0 +1,45 @@
+package playlist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/foxbot/gavalink"
+)
+
+// jsonVersion is the version of gavalink's JSON format
+const jsonVersion = 1
+
+type jsonPlaylist struct {
+	Version int              `json:"version"`
+	Name    string           `json:"name,omitempty"`
+	Tracks  []gavalink.Track `json:"tracks"`
+}
+
+// EncodeJSON writes a playlist in gavalink's JSON format, which keeps
+// the encoded tracks
+func EncodeJSON(w io.Writer, playlist *Playlist) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonPlaylist{
+		Version: jsonVersion,
+		Name:    playlist.Name,
+		Tracks:  playlist.Tracks,
+	})
+}
+
+// DecodeJSON reads a playlist in gavalink's JSON format
+func DecodeJSON(r io.Reader) (*Playlist, error) {
+	var doc jsonPlaylist
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc.Version > jsonVersion {
+		return nil, fmt.Errorf("unsupported playlist version %d", doc.Version)
+	}
+	return &Playlist{
+		Name:   doc.Name,
+		Tracks: doc.Tracks,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package playlist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/foxbot/gavalink"
+)
+
+// EncodeM3U writes a playlist as extended M3U
+//
+// Tracks are written with their URI. Tracks without one can't be
+// opened by other players, so they are left out, and only noted in a
+// comment players ignore. M3U8 is M3U encoded as UTF-8, so this writes
+// M3U8 as well.
+func EncodeM3U(w io.Writer, playlist *Playlist) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("#EXTM3U\n")
+	if playlist.Name != "" {
+		fmt.Fprintf(bw, "#PLAYLIST:%s\n", oneLine(playlist.Name))
+	}
+
+	for _, t := range playlist.Tracks {
+		seconds := -1
+		if !t.Info.Stream {
+			seconds = t.Info.Length / 1000
+		}
+		title := t.Info.Title
+		if t.Info.Author != "" {
+			title = t.Info.Author + " - " + title
+		}
+		if t.Info.URI == "" {
+			fmt.Fprintf(bw, "# no URI: %s (%s %s)\n", oneLine(title), oneLine(t.Info.SourceName), oneLine(t.Info.Identifier))
+			continue
+		}
+		fmt.Fprintf(bw, "#EXTINF:%d,%s\n%s\n", seconds, oneLine(title), oneLine(t.Info.URI))
+	}
+	return bw.Flush()
+}
+
+// DecodeM3U reads an M3U or M3U8 playlist
+//
+// The tracks returned have no track data; their title, author and
+// length are taken from EXTINF lines, if present.
+func DecodeM3U(r io.Reader) (*Playlist, error) {
+	playlist := new(Playlist)
+	var info gavalink.TrackInfo
+
+	scanner := bufio.NewScanner(r)
+	for first := true; scanner.Scan(); first = false {
+		line := strings.TrimSpace(scanner.Text())
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF:"):
+			info = parseExtinf(strings.TrimPrefix(line, "#EXTINF:"))
+		case strings.HasPrefix(line, "#PLAYLIST:"):
+			playlist.Name = strings.TrimPrefix(line, "#PLAYLIST:")
+		case strings.HasPrefix(line, "#"):
+		default:
+			info.URI = line
+			if info.Title == "" {
+				info.Title = line
+			}
+			playlist.Tracks = append(playlist.Tracks, gavalink.Track{Info: info})
+			info = gavalink.TrackInfo{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return playlist, nil
+}
+
+// parseExtinf parses `<seconds> [attributes],[<author> - ]<title>`
+func parseExtinf(s string) gavalink.TrackInfo {
+	var info gavalink.TrackInfo
+
+	duration, title, _ := strings.Cut(s, ",")
+	if fields := strings.Fields(duration); len(fields) > 0 {
+		if seconds, err := strconv.ParseFloat(fields[0], 64); err == nil {
+			if seconds < 0 {
+				info.Stream = true
+			} else {
+				info.Length = int(seconds * 1000)
+			}
+		}
+	}
+
+	if author, t, ok := strings.Cut(title, " - "); ok {
+		info.Author = strings.TrimSpace(author)
+		title = t
+	}
+	info.Title = strings.TrimSpace(title)
+	return info
+}
+
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,126 @@
+// Package playlist imports and exports lists of tracks
+//
+// Playlists are encoded as M3U, XSPF or gavalink's JSON format. Only
+// the JSON format and XSPF keep the encoded Lavaplayer tracks; tracks
+// decoded from M3U carry their location and metadata only, and are
+// loaded again with Resolve:
+//
+//	list, err := playlist.DecodeM3U(r)
+//	tracks, failed := playlist.Resolve(ctx, node, list.Tracks, 4)
+package playlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/foxbot/gavalink"
+)
+
+// ErrNoMatches is returned when a playlist entry matches no track
+var ErrNoMatches = errors.New("No track matches the entry")
+
+// Playlist is a named list of tracks
+type Playlist struct {
+	Name   string
+	Tracks []gavalink.Track
+}
+
+// Loader loads tracks, like a *gavalink.Node
+type Loader interface {
+	LoadTracksContext(ctx context.Context, query string) (*gavalink.Tracks, error)
+}
+
+// Failure is an entry Resolve couldn't load
+type Failure struct {
+	// Index is the entry's index in the tracks passed to Resolve
+	Index int
+	Track gavalink.Track
+	Err   error
+}
+
+func (f Failure) Error() string {
+	return fmt.Sprintf("entry %d (%s): %v", f.Index, Query(f.Track), f.Err)
+}
+
+func (f Failure) Unwrap() error {
+	return f.Err
+}
+
+// Query returns the query an entry without track data is loaded with:
+// its location, or a YouTube search for its author and title
+func Query(track gavalink.Track) string {
+	if track.Info.URI != "" {
+		return track.Info.URI
+	}
+	if track.Info.Author == "" {
+		return "ytsearch:" + track.Info.Title
+	}
+	return "ytsearch:" + track.Info.Author + " " + track.Info.Title
+}
+
+// Resolve loads the entries of tracks which have no track data, with up
+// to concurrency loads at once
+//
+// The tracks are returned in their original order, without the entries
+// which failed to load; those are reported in failed. Entries loading
+// to several tracks, like searches, resolve to the first one.
+func Resolve(ctx context.Context, loader Loader, tracks []gavalink.Track, concurrency int) (resolved []gavalink.Track, failed []Failure) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]gavalink.Track, len(tracks))
+	errs := make([]error, len(tracks))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, t := range tracks {
+		if t.Data != "" {
+			results[i] = t
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int, t gavalink.Track) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = resolve(ctx, loader, t)
+		}(i, t)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, Failure{i, tracks[i], err})
+			continue
+		}
+		resolved = append(resolved, results[i])
+	}
+	return resolved, failed
+}
+
+func resolve(ctx context.Context, loader Loader, track gavalink.Track) (gavalink.Track, error) {
+	loaded, err := loader.LoadTracksContext(ctx, Query(track))
+	if err != nil {
+		return gavalink.Track{}, err
+	}
+	if len(loaded.Tracks) == 0 {
+		return gavalink.Track{}, ErrNoMatches
+	}
+
+	i := 0
+	if loaded.Type == gavalink.PlaylistLoaded && loaded.PlaylistInfo != nil {
+		if s := loaded.PlaylistInfo.SelectedTrack; s >= 0 && s < len(loaded.Tracks) {
+			i = s
+		}
+	}
+	return loaded.Tracks[i], nil
+}
@@ -0,0 +1,167 @@
+package playlist_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/foxbot/gavalink"
+	"github.com/foxbot/gavalink/playlist"
+)
+
+var testPlaylist = &playlist.Playlist{
+	Name: "mix",
+	Tracks: []gavalink.Track{
+		{
+			Data: "QAAAkAIA",
+			Info: gavalink.TrackInfo{
+				Title:  "Song",
+				Author: "Artist",
+				URI:    "https://www.youtube.com/watch?v=hHW1oY26kxQ",
+				Length: 215000,
+			},
+		},
+		{
+			Info: gavalink.TrackInfo{
+				Title:  "Radio",
+				URI:    "https://radio.example/stream",
+				Stream: true,
+			},
+		},
+	},
+}
+
+func TestRoundTrip(t *testing.T) {
+	formats := map[string]struct {
+		encode func(io.Writer, *playlist.Playlist) error
+		decode func(io.Reader) (*playlist.Playlist, error)
+	}{
+		"m3u":  {playlist.EncodeM3U, playlist.DecodeM3U},
+		"xspf": {playlist.EncodeXSPF, playlist.DecodeXSPF},
+		"json": {playlist.EncodeJSON, playlist.DecodeJSON},
+	}
+	for name, format := range formats {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := format.encode(&buf, testPlaylist); err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := format.decode(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded.Name != testPlaylist.Name || len(decoded.Tracks) != len(testPlaylist.Tracks) {
+				t.Fatalf("decoded %+v", decoded)
+			}
+
+			for i, want := range testPlaylist.Tracks {
+				got := decoded.Tracks[i]
+				if name == "m3u" {
+					// M3U has no track data, and no length for streams
+					want.Data = ""
+				}
+				if name == "xspf" {
+					want.Info.Stream = false
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("track %d is %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeM3U(t *testing.T) {
+	data := "\ufeff#EXTM3U\n#EXTINF:123 tvg-id=\"x\",Artist - Title - Live\nhttp://a\n\nhttp://b\n"
+	list, err := playlist.DecodeM3U(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gavalink.TrackInfo{
+		{Title: "Title - Live", Author: "Artist", URI: "http://a", Length: 123000},
+		{Title: "http://b", URI: "http://b"},
+	}
+	for i, track := range list.Tracks {
+		if track.Info != want[i] {
+			t.Errorf("track %d is %+v, want %+v", i, track.Info, want[i])
+		}
+	}
+}
+
+func TestEncodeM3UWithoutURI(t *testing.T) {
+	list := &playlist.Playlist{Tracks: []gavalink.Track{
+		{Info: gavalink.TrackInfo{Title: "Local", Identifier: "/music/local.mp3", SourceName: "local"}},
+		testPlaylist.Tracks[0],
+	}}
+	var buf bytes.Buffer
+	if err := playlist.EncodeM3U(&buf, list); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := playlist.DecodeM3U(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the track without a URI is left out
+	if len(decoded.Tracks) != 1 || decoded.Tracks[0].Info != testPlaylist.Tracks[0].Info {
+		t.Errorf("decoded %+v, want only the track with a URI", decoded.Tracks)
+	}
+}
+
+type testLoader struct {
+	running, max int32
+}
+
+func (l *testLoader) LoadTracksContext(ctx context.Context, query string) (*gavalink.Tracks, error) {
+	n := atomic.AddInt32(&l.running, 1)
+	defer atomic.AddInt32(&l.running, -1)
+	for {
+		max := atomic.LoadInt32(&l.max)
+		if n <= max || atomic.CompareAndSwapInt32(&l.max, max, n) {
+			break
+		}
+	}
+
+	if strings.Contains(query, "missing") {
+		return &gavalink.Tracks{Type: gavalink.NoMatches}, nil
+	}
+	return &gavalink.Tracks{
+		Type:   gavalink.SearchResult,
+		Tracks: []gavalink.Track{{Data: query}},
+	}, nil
+}
+
+func TestResolve(t *testing.T) {
+	tracks := []gavalink.Track{
+		{Info: gavalink.TrackInfo{Title: "a", Author: "x"}},
+		{Data: "loaded"},
+		{Info: gavalink.TrackInfo{Title: "missing"}},
+		{Info: gavalink.TrackInfo{URI: "http://b"}},
+	}
+	for i := 0; i < 16; i++ {
+		tracks = append(tracks, gavalink.Track{Info: gavalink.TrackInfo{Title: "c"}})
+	}
+
+	loader := new(testLoader)
+	resolved, failed := playlist.Resolve(context.Background(), loader, tracks, 3)
+
+	if len(failed) != 1 || failed[0].Index != 2 || !errors.Is(failed[0], playlist.ErrNoMatches) {
+		t.Errorf("failed %v, want entry 2 with no matches", failed)
+	}
+	want := []string{"ytsearch:x a", "loaded", "http://b"}
+	for i, w := range want {
+		if resolved[i].Data != w {
+			t.Errorf("resolved %d to %q, want %q", i, resolved[i].Data, w)
+		}
+	}
+	if len(resolved) != len(tracks)-1 {
+		t.Errorf("resolved %d tracks, want %d", len(resolved), len(tracks)-1)
+	}
+	if max := atomic.LoadInt32(&loader.max); max > 3 {
+		t.Errorf("ran %d loads at once, want at most 3", max)
+	}
+}
@@ -0,0 +1,103 @@
+package playlist
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/foxbot/gavalink"
+)
+
+const (
+	xspfNamespace = "http://xspf.org/ns/0/"
+	// xspfTrackRel marks the meta element holding the encoded track
+	xspfTrackRel = "https://github.com/foxbot/gavalink#track"
+)
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"playlist"`
+	Xmlns   string      `xml:"xmlns,attr,omitempty"`
+	Version string      `xml:"version,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string     `xml:"location,omitempty"`
+	Title    string     `xml:"title,omitempty"`
+	Creator  string     `xml:"creator,omitempty"`
+	Duration int        `xml:"duration,omitempty"`
+	Meta     []xspfMeta `xml:"meta,omitempty"`
+}
+
+type xspfMeta struct {
+	Rel   string `xml:"rel,attr"`
+	Value string `xml:",chardata"`
+}
+
+// EncodeXSPF writes a playlist as XSPF
+//
+// The encoded tracks are kept in a meta element, so DecodeXSPF restores
+// them without loading them again.
+func EncodeXSPF(w io.Writer, playlist *Playlist) error {
+	doc := xspfPlaylist{
+		Xmlns:   xspfNamespace,
+		Version: "1",
+		Title:   playlist.Name,
+		Tracks:  make([]xspfTrack, len(playlist.Tracks)),
+	}
+	for i, t := range playlist.Tracks {
+		track := xspfTrack{
+			Location: t.Info.URI,
+			Title:    t.Info.Title,
+			Creator:  t.Info.Author,
+		}
+		if !t.Info.Stream {
+			track.Duration = t.Info.Length
+		}
+		if t.Data != "" {
+			track.Meta = []xspfMeta{{xspfTrackRel, t.Data}}
+		}
+		doc.Tracks[i] = track
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// DecodeXSPF reads an XSPF playlist
+func DecodeXSPF(r io.Reader) (*Playlist, error) {
+	var doc xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	playlist := &Playlist{
+		Name:   doc.Title,
+		Tracks: make([]gavalink.Track, len(doc.Tracks)),
+	}
+	for i, t := range doc.Tracks {
+		track := gavalink.Track{
+			Info: gavalink.TrackInfo{
+				URI:    t.Location,
+				Title:  t.Title,
+				Author: t.Creator,
+				Length: t.Duration,
+			},
+		}
+		for _, m := range t.Meta {
+			if m.Rel == xspfTrackRel {
+				track.Data = m.Value
+			}
+		}
+		playlist.Tracks[i] = track
+	}
+	return playlist, nil
+}
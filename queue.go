@@ -0,0 +1,117 @@
+package gavalink
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Queue is a list of tracks waiting to be played
+//
+// A Queue is safe for concurrent use.
+type Queue struct {
+	mu     sync.Mutex
+	tracks []Track
+}
+
+// Add appends tracks to the end of the queue
+func (queue *Queue) Add(tracks ...Track) {
+	queue.mu.Lock()
+	queue.tracks = append(queue.tracks, tracks...)
+	queue.mu.Unlock()
+}
+
+// Insert inserts tracks into the queue before index i
+//
+// Indexes beyond the end of the queue append the tracks.
+func (queue *Queue) Insert(i int, tracks ...Track) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	if i < 0 {
+		i = 0
+	}
+	if i > len(queue.tracks) {
+		i = len(queue.tracks)
+	}
+	queue.tracks = slices.Insert(queue.tracks, i, tracks...)
+}
+
+// Next removes and returns the track at the front of the queue
+//
+// ok is false if the queue is empty.
+func (queue *Queue) Next() (track Track, ok bool) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	if len(queue.tracks) == 0 {
+		return Track{}, false
+	}
+	track = queue.tracks[0]
+	queue.tracks[0] = Track{}
+	queue.tracks = queue.tracks[1:]
+	return track, true
+}
+
+// Peek returns the track at the front of the queue, without removing it
+func (queue *Queue) Peek() (track Track, ok bool) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	if len(queue.tracks) == 0 {
+		return Track{}, false
+	}
+	return queue.tracks[0], true
+}
+
+// Remove removes the track at index i from the queue
+func (queue *Queue) Remove(i int) bool {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	if i < 0 || i >= len(queue.tracks) {
+		return false
+	}
+	queue.tracks = append(queue.tracks[:i], queue.tracks[i+1:]...)
+	return true
+}
+
+// Len returns the amount of tracks in the queue
+func (queue *Queue) Len() int {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	return len(queue.tracks)
+}
+
+// Tracks returns a copy of the tracks in the queue
+func (queue *Queue) Tracks() []Track {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	tracks := make([]Track, len(queue.tracks))
+	copy(tracks, queue.tracks)
+	return tracks
+}
+
+// Clear removes all tracks from the queue
+func (queue *Queue) Clear() {
+	queue.mu.Lock()
+	queue.tracks = nil
+	queue.mu.Unlock()
+}
+
+// Queue returns the player's queue
+func (player *Player) Queue() *Queue {
+	return &player.queue
+}
+
+// PlayNext plays the next track of the player's queue
+//
+// PlayNext returns ErrQueueEmpty if there is nothing to play.
+func (player *Player) PlayNext(ctx context.Context) error {
+	track, ok := player.queue.Next()
+	if !ok {
+		return ErrQueueEmpty
+	}
+	return player.PlayContext(ctx, track.Data)
+}
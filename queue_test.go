@@ -0,0 +1,31 @@
+package gavalink_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/foxbot/gavalink"
+)
+
+func TestQueueInsert(t *testing.T) {
+	var queue gavalink.Queue
+	queue.Add(gavalink.Track{Data: "a"}, gavalink.Track{Data: "d"})
+
+	// spare capacity in the caller's slice must not be written to
+	tracks := make([]gavalink.Track, 2, 4)
+	tracks[0], tracks[1] = gavalink.Track{Data: "b"}, gavalink.Track{Data: "c"}
+	queue.Insert(1, tracks...)
+	queue.Insert(10, gavalink.Track{Data: "e"})
+	queue.Insert(-1)
+
+	var got []string
+	for _, track := range queue.Tracks() {
+		got = append(got, track.Data)
+	}
+	if want := []string{"a", "b", "c", "d", "e"}; !slices.Equal(got, want) {
+		t.Errorf("got queue %v, want %v", got, want)
+	}
+	if extra := tracks[:4]; extra[2].Data != "" || extra[3].Data != "" {
+		t.Errorf("Insert wrote to the caller's slice: %v", extra)
+	}
+}
@@ -0,0 +1,64 @@
+package gavalink
+
+import (
+	"strings"
+	"time"
+)
+
+// regionPenalty is added to the penalty of nodes outside the requested
+// region, so that they are only picked when no node in the region is
+// available
+const regionPenalty = 1000
+
+// VoiceRegion returns the Discord voice region of a voice server
+// endpoint, e.g. `us-east` for `us-east123.discord.media:443`, or
+// `ams` for `c-ams05-a1b2c3d4.discord.media`
+//
+// Hints which are already a region are returned unchanged, in lower case.
+func VoiceRegion(endpoint string) string {
+	region := strings.ToLower(endpoint)
+	if i := strings.Index(region, "://"); i != -1 {
+		region = region[i+3:]
+	}
+	if i := strings.IndexAny(region, ".:"); i != -1 {
+		region = region[:i]
+	}
+
+	// newer endpoints look like c-ams05-a1b2c3d4.discord.media
+	if strings.HasPrefix(region, "c-") {
+		region = region[2:]
+		if i := strings.Index(region, "-"); i != -1 {
+			region = region[:i]
+		}
+	}
+
+	return strings.TrimRight(region, "0123456789")
+}
+
+// inRegion returns whether the node serves the given region
+func (node *Node) inRegion(region string) bool {
+	for _, r := range node.config.Regions {
+		if strings.EqualFold(r, region) {
+			return true
+		}
+	}
+	return false
+}
+
+// Penalty scores how suitable this node is for a player in the given
+// region, which may be empty; lower is better
+//
+// The penalty grows with the node's load and the round trip time of its
+// websocket. Nodes outside the region are penalized heavily, so that
+// they only win if no node in the region is available.
+func (node *Node) Penalty(region string) float32 {
+	node.mu.Lock()
+	p := node.load * 100
+	node.mu.Unlock()
+	// one point per 10ms of websocket round trip time
+	p += float32(node.Ping()) / float32(10*time.Millisecond)
+	if region != "" && !node.inRegion(region) {
+		p += regionPenalty
+	}
+	return p
+}
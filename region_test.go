@@ -0,0 +1,65 @@
+package gavalink_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/foxbot/gavalink"
+)
+
+func TestVoiceRegion(t *testing.T) {
+	tests := map[string]string{
+		"us-east123.discord.media:443":     "us-east",
+		"rotterdam1234.discord.media":      "rotterdam",
+		"wss://eu-central5.discord.gg:443": "eu-central",
+		"c-ams05-a1b2c3d4.discord.media":   "ams",
+		"US-West":                          "us-west",
+		"":                                 "",
+	}
+	for endpoint, want := range tests {
+		if got := gavalink.VoiceRegion(endpoint); got != want {
+			t.Errorf("VoiceRegion(%q) = %q, want %q", endpoint, got, want)
+		}
+	}
+}
+
+func TestBestNodeRegion(t *testing.T) {
+	srv := newTestServer(t)
+	lavalink := gavalink.NewLavalink("1", "1")
+	defer lavalink.Close(context.Background(), false)
+
+	amsterdam := testNodeConfig(srv, "amsterdam")
+	amsterdam.Regions = []string{"ams", "rotterdam"}
+	east := testNodeConfig(srv, "east")
+	east.Regions = []string{"us-east"}
+	if err := lavalink.AddNodes(amsterdam, east); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"c-ams05-a1b2c3d4.discord.media:443": "amsterdam",
+		"rotterdam1234.discord.media":        "amsterdam",
+		"us-east123.discord.media:443":       "east",
+	}
+	for endpoint, want := range tests {
+		node, err := lavalink.BestNode(endpoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Name() != want {
+			t.Errorf("BestNode(%q) picked %s, want %s", endpoint, node.Name(), want)
+		}
+	}
+
+	// without a node in the region, any node will do
+	if _, err := lavalink.BestNode("c-fra01-a1b2c3d4.discord.media"); err != nil {
+		t.Errorf("BestNode outside every region returned %v", err)
+	}
+	if err := lavalink.RemoveNode("east"); err != nil {
+		t.Fatal(err)
+	}
+	node, err := lavalink.BestNode("us-east123.discord.media:443")
+	if err != nil || node.Name() != "amsterdam" {
+		t.Errorf("BestNode without a node in us-east returned %v, %v, want amsterdam", node, err)
+	}
+}
@@ -0,0 +1,258 @@
+package gavalink
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultResolveConcurrency is how many tracks ResolveAll loads from a
+// node at once by default
+const DefaultResolveConcurrency = 4
+
+// ResolveResult is the result of loading one of the queries passed to
+// ResolveAll
+type ResolveResult struct {
+	// Index is the query's index in the queries passed to ResolveAll
+	Index  int
+	Query  string
+	Tracks *Tracks
+	// Node is the name of the node which loaded the query
+	Node string
+	Err  error
+}
+
+// ResolveOption configures ResolveAll
+type ResolveOption func(*resolveConfig)
+
+type resolveConfig struct {
+	concurrency int
+	rate        float64
+	onResult    func(ResolveResult)
+}
+
+// ResolveConcurrency sets how many tracks ResolveAll loads from each
+// node at once
+func ResolveConcurrency(n int) ResolveOption {
+	return func(c *resolveConfig) {
+		c.concurrency = n
+	}
+}
+
+// ResolveRate limits how many tracks ResolveAll loads from each node
+// per second
+func ResolveRate(perSecond float64) ResolveOption {
+	return func(c *resolveConfig) {
+		c.rate = perSecond
+	}
+}
+
+// OnResolved sets a callback receiving each result as soon as it is
+// loaded, in no particular order
+//
+// The callback is never called concurrently.
+func OnResolved(callback func(ResolveResult)) ResolveOption {
+	return func(c *resolveConfig) {
+		c.onResult = callback
+	}
+}
+
+// resolveMaxErrors is how many loads in a row may fail on a node before
+// ResolveAll stops giving it queries
+const resolveMaxErrors = 3
+
+// resolveNode is a node loading queries for ResolveAll
+type resolveNode struct {
+	node  *Node
+	limit *limiter
+	// retries receives the queries which failed on another node
+	retries chan int
+
+	mu     sync.Mutex
+	errors int
+	failed bool
+}
+
+// fail counts a failed load, and reports whether the node just failed
+// too many in a row
+func (n *resolveNode) fail() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.errors++
+	if n.failed || n.errors < resolveMaxErrors {
+		return false
+	}
+	n.failed = true
+	return true
+}
+
+func (n *resolveNode) succeed() {
+	n.mu.Lock()
+	n.errors = 0
+	n.mu.Unlock()
+}
+
+func (n *resolveNode) healthy() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return !n.failed
+}
+
+// ResolveAll loads many queries, spreading them across the manager's
+// nodes
+//
+// The results are returned in the order of the queries; each carries
+// its own error. A query which fails on a node is retried once on
+// another, and a node which fails several loads in a row isn't given
+// any more. Queries left when ctx is done, or once every node failed,
+// fail with ctx's error or the last node's.
+func (lavalink *Lavalink) ResolveAll(ctx context.Context, queries []string, options ...ResolveOption) []ResolveResult {
+	config := resolveConfig{concurrency: DefaultResolveConcurrency}
+	for _, option := range options {
+		option(&config)
+	}
+	if config.concurrency < 1 {
+		config.concurrency = 1
+	}
+
+	results := make([]ResolveResult, len(queries))
+	for i, q := range queries {
+		results[i] = ResolveResult{Index: i, Query: q}
+	}
+
+	var callbackMu sync.Mutex
+	done := func(result ResolveResult) {
+		results[result.Index] = result
+		if config.onResult != nil {
+			callbackMu.Lock()
+			config.onResult(result)
+			callbackMu.Unlock()
+		}
+	}
+
+	var nodes []*resolveNode
+	for _, n := range lavalink.Nodes() {
+		if n.Draining() || n.isClosed() {
+			continue
+		}
+		rn := &resolveNode{node: n, retries: make(chan int, len(queries))}
+		if config.rate > 0 {
+			rn.limit = newLimiter(config.rate, config.concurrency)
+		}
+		nodes = append(nodes, rn)
+	}
+	if len(nodes) == 0 {
+		for _, r := range results {
+			r.Err = ErrNoNodes
+			done(r)
+		}
+		return results
+	}
+
+	// retryOn returns a healthy node other than failed to retry a query
+	// on, if any
+	retryOn := func(failed *resolveNode) *resolveNode {
+		for _, n := range nodes {
+			if n != failed && n.healthy() {
+				return n
+			}
+		}
+		return nil
+	}
+
+	jobs := make(chan int)
+	// pending counts the queries without a result
+	var pending sync.WaitGroup
+	pending.Add(len(queries))
+	finished := make(chan struct{})
+	// allFailed is closed once no node is healthy anymore, lastErr is
+	// the error the last one failed with
+	allFailed := make(chan struct{})
+	var failuresMu sync.Mutex
+	var failures int
+	var lastErr error
+
+	var wg sync.WaitGroup
+	for _, n := range nodes {
+		for i := 0; i < config.concurrency; i++ {
+			wg.Add(1)
+			go func(n *resolveNode) {
+				defer wg.Done()
+				queue := jobs
+				for {
+					var i int
+					var retried, ok bool
+					// unhealthy nodes only finish the retries they were given
+					next := queue
+					if !n.healthy() {
+						next = nil
+					}
+					select {
+					case i = <-n.retries:
+						retried = true
+					case i, ok = <-next:
+						if !ok {
+							queue = nil
+							continue
+						}
+					case <-finished:
+						return
+					}
+
+					result := results[i]
+					result.Node = n.node.Name()
+					if _, err := n.limit.wait(ctx); err != nil {
+						result.Err = err
+					} else {
+						result.Tracks, result.Err = n.node.LoadTracksContext(ctx, result.Query)
+					}
+
+					if result.Err == nil {
+						n.succeed()
+					} else if ctx.Err() == nil {
+						if n.fail() {
+							failuresMu.Lock()
+							failures++
+							lastErr = result.Err
+							if failures == len(nodes) {
+								close(allFailed)
+							}
+							failuresMu.Unlock()
+						}
+						if other := retryOn(n); !retried && other != nil {
+							other.retries <- i
+							continue
+						}
+					}
+					done(result)
+					pending.Done()
+				}
+			}(n)
+		}
+	}
+
+	for i := range queries {
+		var err error
+		select {
+		case jobs <- i:
+			continue
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-allFailed:
+			failuresMu.Lock()
+			err = lastErr
+			failuresMu.Unlock()
+		}
+		for _, r := range results[i:] {
+			r.Err = err
+			done(r)
+			pending.Done()
+		}
+		break
+	}
+	close(jobs)
+	pending.Wait()
+	close(finished)
+	wg.Wait()
+
+	return results
+}
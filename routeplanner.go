@@ -0,0 +1,107 @@
+package gavalink
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const (
+	// RotatingIPRoutePlanner switches IP on ban
+	RotatingIPRoutePlanner = "RotatingIpRoutePlanner"
+	// NanoIPRoutePlanner switches IP on every clock update
+	NanoIPRoutePlanner = "NanoIpRoutePlanner"
+	// RotatingNanoIPRoutePlanner switches IP on every clock update, and
+	// rotates to a different block on ban
+	RotatingNanoIPRoutePlanner = "RotatingNanoIpRoutePlanner"
+	// BalancingIPRoutePlanner selects a random IP for every request
+	BalancingIPRoutePlanner = "BalancingIpRoutePlanner"
+)
+
+// RoutePlannerStatus contains the state of a node's route planner
+type RoutePlannerStatus struct {
+	// Class is the route planner implementation, one of the
+	// RoutePlanner constants, or empty if the node has no route planner
+	Class   string               `json:"class"`
+	Details *RoutePlannerDetails `json:"details"`
+}
+
+// RoutePlannerDetails contains the details of a route planner
+//
+// Which indexes are set depends on the route planner class; Lavalink
+// sends them as strings, since they may exceed 64 bits.
+type RoutePlannerDetails struct {
+	IPBlock          IPBlock          `json:"ipBlock"`
+	FailingAddresses []FailingAddress `json:"failingAddresses"`
+	// RotateIndex is the number of rotations, for RotatingIPRoutePlanner
+	RotateIndex string `json:"rotateIndex"`
+	// IPIndex is the current offset in the block, for
+	// RotatingIPRoutePlanner
+	IPIndex string `json:"ipIndex"`
+	// CurrentAddress is the current address, for RotatingIPRoutePlanner
+	CurrentAddress string `json:"currentAddress"`
+	// CurrentAddressIndex is the current offset in the block, for the
+	// Nano route planners
+	CurrentAddressIndex string `json:"currentAddressIndex"`
+	// BlockIndex is the current /64 block, for
+	// RotatingNanoIPRoutePlanner
+	BlockIndex string `json:"blockIndex"`
+}
+
+// IPBlock is the IP block a route planner uses
+type IPBlock struct {
+	// Type is Inet4Address or Inet6Address
+	Type string `json:"type"`
+	// Size is the amount of addresses in the block
+	Size string `json:"size"`
+}
+
+// FailingAddress is an address the route planner marked as failing
+type FailingAddress struct {
+	Address string `json:"address"`
+	// FailingTimestamp is when the address failed, in millis since the
+	// epoch
+	FailingTimestamp int64 `json:"failingTimestamp"`
+	// FailingTime is FailingTimestamp, formatted by Lavalink
+	FailingTime string `json:"failingTime"`
+}
+
+// Time returns when the address failed
+func (a FailingAddress) Time() time.Time {
+	return time.Unix(0, a.FailingTimestamp*int64(time.Millisecond))
+}
+
+// RoutePlannerStatus gets the state of the node's route planner
+//
+// RoutePlannerStatus returns nil if the node has no route planner.
+func (node *Node) RoutePlannerStatus(ctx context.Context) (*RoutePlannerStatus, error) {
+	var data string
+	err := node.rest(ctx, "routeplanner/status", http.MethodGet, "/routeplanner/status", nil, &data)
+	if err != nil {
+		return nil, err
+	}
+	// nodes without a route planner answer 204, with no body
+	if data == "" {
+		return nil, nil
+	}
+	status := new(RoutePlannerStatus)
+	if err = json.Unmarshal([]byte(data), status); err != nil {
+		return nil, &NodeError{node.config.Name, err}
+	}
+	return status, nil
+}
+
+// FreeAddress unmarks a failing address, so the route planner uses it
+// again
+func (node *Node) FreeAddress(ctx context.Context, address string) error {
+	body := struct {
+		Address string `json:"address"`
+	}{address}
+	return node.rest(ctx, "routeplanner/free/address", http.MethodPost, "/routeplanner/free/address", body, nil)
+}
+
+// FreeAllAddresses unmarks every failing address
+func (node *Node) FreeAllAddresses(ctx context.Context) error {
+	return node.rest(ctx, "routeplanner/free/all", http.MethodPost, "/routeplanner/free/all", nil, nil)
+}
@@ -0,0 +1,87 @@
+package gavalink_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/foxbot/gavalink"
+)
+
+func TestRoutePlannerStatus(t *testing.T) {
+	srv := newTestServer(t)
+	lavalink, _ := newTestPlayer(t, srv, gavalink.DummyEventHandler{})
+	node, err := lavalink.BestNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Handle("/routeplanner/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"class":"RotatingIpRoutePlanner","details":{"ipBlock":{"type":"Inet6Address","size":"1208925819614629174706176"},"failingAddresses":[{"address":"/1.0.0.0","failingTimestamp":1573520707545,"failingTime":"Mon Nov 11 20:05:07 EST 2019"}],"rotateIndex":"1","ipIndex":"1","currentAddress":"1"}}`))
+	})
+	status, err := node.RoutePlannerStatus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status == nil || status.Class != gavalink.RotatingIPRoutePlanner || status.Details == nil {
+		t.Fatalf("got status %+v", status)
+	}
+	if failing := status.Details.FailingAddresses; len(failing) != 1 || failing[0].Address != "/1.0.0.0" || failing[0].Time().UnixMilli() != 1573520707545 {
+		t.Errorf("got failing addresses %+v", failing)
+	}
+	if status.Details.IPBlock.Size != "1208925819614629174706176" {
+		t.Errorf("got block size %s", status.Details.IPBlock.Size)
+	}
+
+	srv.Handle("/routeplanner/status", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	status, err = node.RoutePlannerStatus(context.Background())
+	if err != nil || status != nil {
+		t.Errorf("got status %+v, %v without a route planner, want nil", status, err)
+	}
+}
+
+func TestFreeAddress(t *testing.T) {
+	srv := newTestServer(t)
+	lavalink, _ := newTestPlayer(t, srv, gavalink.DummyEventHandler{})
+	node, err := lavalink.BestNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	freed := make(chan string, 2)
+	srv.Handle("/routeplanner/free/address", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Address string `json:"address"`
+		}
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&body) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		freed <- body.Address
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srv.Handle("/routeplanner/free/all", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		freed <- "all"
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err = node.FreeAddress(context.Background(), "1.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if address := <-freed; address != "1.0.0.1" {
+		t.Errorf("freed %s, want 1.0.0.1", address)
+	}
+	if err = node.FreeAllAddresses(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if address := <-freed; address != "all" {
+		t.Errorf("freed %s, want all", address)
+	}
+}
@@ -0,0 +1,232 @@
+package gavalink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Snapshot is the state of a manager's players, which can be restored
+// after the process restarts
+type Snapshot struct {
+	// Time is when the snapshot was taken
+	Time    time.Time     `json:"time"`
+	Players []PlayerState `json:"players"`
+}
+
+// PlayerState is the state of a single player
+type PlayerState struct {
+	GuildID string `json:"guildId"`
+	// Node is the name of the node the player was playing on
+	Node string `json:"node"`
+	// SessionID is the Discord voice session ID of the player
+	SessionID string            `json:"sessionId"`
+	Server    VoiceServerUpdate `json:"server"`
+	Track     string            `json:"track,omitempty"`
+	// Position is the player's position when the snapshot was taken, in
+	// millis
+	Position int      `json:"position"`
+	Volume   int      `json:"volume"`
+	Paused   bool     `json:"paused"`
+	Filters  *Filters `json:"filters,omitempty"`
+	Queue    []Track  `json:"queue,omitempty"`
+	// History is the player's history, oldest first
+	History []HistoryEntry `json:"history,omitempty"`
+}
+
+// Snapshot captures the state of every player of this manager
+func (lavalink *Lavalink) Snapshot() *Snapshot {
+	lavalink.mu.RLock()
+	players := make([]*Player, 0, len(lavalink.players))
+	for _, p := range lavalink.players {
+		players = append(players, p)
+	}
+	lavalink.mu.RUnlock()
+
+	snapshot := &Snapshot{
+		Time:    time.Now(),
+		Players: make([]PlayerState, len(players)),
+	}
+	for i, p := range players {
+		snapshot.Players[i] = p.state()
+	}
+	return snapshot
+}
+
+func (player *Player) state() PlayerState {
+	position := player.interpolatedPosition()
+	queue, history := player.queue.Tracks(), player.history.Entries()
+
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	return PlayerState{
+		GuildID:   player.guildID,
+		Node:      player.node.Name(),
+		SessionID: player.sessionID,
+		Server:    player.server,
+		Track:     player.track,
+		Position:  position,
+		Volume:    player.vol,
+		Paused:    player.paused,
+		Filters:   player.filters,
+		Queue:     queue,
+		History:   history,
+	}
+}
+
+// Restore recreates the players of a snapshot, with the event handlers
+// returned by handler
+//
+// Players whose node resumed its session, see NodeConfig.ResumeKey, are
+// reattached as they are, since Lavalink kept playing them. Others are
+// recreated on their node, or on the best node if theirs is gone, and
+// resume their track from where the snapshot left it.
+//
+// Guilds which already have a player are skipped. Errors met restoring
+// players are joined in the returned error.
+func (lavalink *Lavalink) Restore(ctx context.Context, snapshot *Snapshot, handler func(guildID string) EventHandler) error {
+	var errs []error
+	for _, state := range snapshot.Players {
+		if _, err := lavalink.GetPlayer(state.GuildID); err == nil {
+			continue
+		}
+		if err := lavalink.restore(ctx, snapshot.Time, state, handler(state.GuildID)); err != nil {
+			errs = append(errs, fmt.Errorf("guild %s: %w", state.GuildID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (lavalink *Lavalink) restore(ctx context.Context, taken time.Time, state PlayerState, handler EventHandler) error {
+	if handler == nil {
+		return ErrNilHandler
+	}
+
+	node, err := lavalink.Node(state.Node)
+	if err == nil && node.Draining() {
+		err = ErrNodeNotFound
+	}
+	if err != nil {
+		if node, err = lavalink.BestNode(state.Server.Endpoint); err != nil {
+			return err
+		}
+	}
+
+	player := &Player{
+		guildID:   state.GuildID,
+		sessionID: state.SessionID,
+		server:    state.Server,
+		position:  state.Position,
+		paused:    state.Paused,
+		vol:       state.Volume,
+		track:     state.Track,
+		filters:   state.Filters,
+		manager:   lavalink,
+		node:      node,
+		handler:   handler,
+	}
+	player.queue.Add(state.Queue...)
+	player.history.size = lavalink.historySize
+	player.history.add(state.History...)
+
+	lavalink.mu.Lock()
+	lavalink.players[state.GuildID] = player
+	lavalink.mu.Unlock()
+
+	if node.Name() == state.Node && node.Resumed() {
+		player.logger().Info("reattached player")
+		return nil
+	}
+
+	if err := player.ForwardContext(ctx, state.SessionID, state.Server); err != nil {
+		return err
+	}
+	if state.Track == "" {
+		return nil
+	}
+
+	position := state.Position
+	if !state.Paused {
+		// the track would have kept playing since
+		position += int(time.Since(taken) / time.Millisecond)
+	}
+	if err := player.play(ctx, state.Track, position, 0); err != nil {
+		return err
+	}
+	if state.Paused {
+		if err := player.PauseContext(ctx, true); err != nil {
+			return err
+		}
+	}
+	if state.Volume != 100 {
+		if err := player.VolumeContext(ctx, state.Volume); err != nil {
+			return err
+		}
+	}
+	if state.Filters != nil {
+		return player.SetFilters(ctx, state.Filters)
+	}
+	return nil
+}
+
+// Store persists snapshots
+type Store interface {
+	// Save stores a snapshot, replacing the one stored before
+	Save(ctx context.Context, snapshot *Snapshot) error
+	// Load returns the last snapshot saved, or an empty snapshot if
+	// none was
+	Load(ctx context.Context) (*Snapshot, error)
+}
+
+// FileStore stores snapshots as JSON in a file
+type FileStore struct {
+	Path string
+}
+
+// Save writes a snapshot to the store's file
+//
+// The snapshot is written to a temporary file first, so a crash never
+// leaves a partial snapshot behind.
+func (store FileStore) Save(ctx context.Context, snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(store.Path), filepath.Base(store.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), store.Path)
+}
+
+// Load reads the snapshot in the store's file
+func (store FileStore) Load(ctx context.Context) (*Snapshot, error) {
+	data, err := os.ReadFile(store.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return new(Snapshot), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := new(Snapshot)
+	if err = json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,144 @@
+package gavalink
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// SponsorBlock categories, see https://wiki.sponsor.ajay.app/w/Types
+const (
+	SegmentSponsor       = "sponsor"
+	SegmentSelfPromo     = "selfpromo"
+	SegmentInteraction   = "interaction"
+	SegmentIntro         = "intro"
+	SegmentOutro         = "outro"
+	SegmentPreview       = "preview"
+	SegmentMusicOfftopic = "music_offtopic"
+	SegmentFiller        = "filler"
+)
+
+// Segment is a SponsorBlock segment of a track
+type Segment struct {
+	Category string `json:"category"`
+	// Start is the start of the segment, in millis
+	Start int `json:"start"`
+	// End is the end of the segment, in millis
+	End int `json:"end"`
+}
+
+// Chapter is a chapter of a track
+type Chapter struct {
+	Name string `json:"name"`
+	// Start is the start of the chapter, in millis
+	Start int `json:"start"`
+	// End is the end of the chapter, in millis
+	End int `json:"end"`
+	// Duration is the duration of the chapter, in millis
+	Duration int `json:"duration"`
+}
+
+// SponsorBlockHandler defines the events Lavalink's SponsorBlock plugin
+// sends to a player
+//
+// An EventHandler which also implements SponsorBlockHandler receives
+// them once the SponsorBlock extension is in use.
+type SponsorBlockHandler interface {
+	OnSegmentsLoaded(player *Player, segments []Segment) error
+	OnSegmentSkipped(player *Player, segment Segment) error
+	OnChaptersLoaded(player *Player, chapters []Chapter) error
+	OnChapterStarted(player *Player, chapter Chapter) error
+}
+
+// SponsorBlock is the Extension for Lavalink's SponsorBlock plugin
+//
+//	lavalink.Use(gavalink.SponsorBlock{})
+type SponsorBlock struct{}
+
+// sponsorBlockCategories is versioned like the node's API, /v3 on
+// Lavalink v3.7 and /v4 on v4
+var sponsorBlockCategories = Route{
+	Name: "sponsorblock/categories",
+	Path: "/v{version}/sessions/{sessionId}/players/{guildId}/sponsorblock/categories",
+}
+
+// Register registers the plugin's events and routes
+func (SponsorBlock) Register(registry *Registry) error {
+	HandleEvent(registry, "SegmentsLoaded", func(player *Player, event struct {
+		Segments []Segment `json:"segments"`
+	}) error {
+		if h, ok := player.handler.(SponsorBlockHandler); ok {
+			return h.OnSegmentsLoaded(player, event.Segments)
+		}
+		return nil
+	})
+	HandleEvent(registry, "SegmentSkipped", func(player *Player, event struct {
+		Segment Segment `json:"segment"`
+	}) error {
+		if h, ok := player.handler.(SponsorBlockHandler); ok {
+			return h.OnSegmentSkipped(player, event.Segment)
+		}
+		return nil
+	})
+	HandleEvent(registry, "ChaptersLoaded", func(player *Player, event struct {
+		Chapters []Chapter `json:"chapters"`
+	}) error {
+		if h, ok := player.handler.(SponsorBlockHandler); ok {
+			return h.OnChaptersLoaded(player, event.Chapters)
+		}
+		return nil
+	})
+	HandleEvent(registry, "ChapterStarted", func(player *Player, event struct {
+		Chapter Chapter `json:"chapter"`
+	}) error {
+		if h, ok := player.handler.(SponsorBlockHandler); ok {
+			return h.OnChapterStarted(player, event.Chapter)
+		}
+		return nil
+	})
+	registry.AddRoute(sponsorBlockCategories)
+	return nil
+}
+
+// sponsorBlock calls the SponsorBlock categories route for this player
+func (player *Player) sponsorBlock(ctx context.Context, method string, body interface{}, out interface{}) error {
+	node := player.Node()
+	sessionID := node.SessionID()
+	if sessionID == "" {
+		return ErrNoSession
+	}
+
+	route := sponsorBlockCategories
+	route.Method = method
+	vars := map[string]string{
+		"version":   strconv.Itoa(node.APIVersion()),
+		"sessionId": sessionID,
+		"guildId":   player.guildID,
+	}
+	return node.Call(ctx, route, vars, body, out)
+}
+
+// SetSponsorBlockCategories sets the SponsorBlock categories skipped
+// for this player
+func (player *Player) SetSponsorBlockCategories(ctx context.Context, categories ...string) error {
+	if categories == nil {
+		categories = []string{}
+	}
+	return player.sponsorBlock(ctx, http.MethodPut, categories, nil)
+}
+
+// GetSponsorBlockCategories gets the SponsorBlock categories skipped for
+// this player
+func (player *Player) GetSponsorBlockCategories(ctx context.Context) ([]string, error) {
+	var categories []string
+	if err := player.sponsorBlock(ctx, http.MethodGet, nil, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// ClearSponsorBlockCategories stops skipping SponsorBlock segments for
+// this player
+func (player *Player) ClearSponsorBlockCategories(ctx context.Context) error {
+	return player.sponsorBlock(ctx, http.MethodDelete, nil, nil)
+}
@@ -0,0 +1,119 @@
+package gavalink_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/foxbot/gavalink"
+)
+
+type sponsorBlockHandler struct {
+	gavalink.DummyEventHandler
+	loaded  chan []gavalink.Segment
+	skipped chan gavalink.Segment
+}
+
+func (h sponsorBlockHandler) OnSegmentsLoaded(player *gavalink.Player, segments []gavalink.Segment) error {
+	h.loaded <- segments
+	return nil
+}
+
+func (h sponsorBlockHandler) OnSegmentSkipped(player *gavalink.Player, segment gavalink.Segment) error {
+	h.skipped <- segment
+	return nil
+}
+
+func (sponsorBlockHandler) OnChaptersLoaded(player *gavalink.Player, chapters []gavalink.Chapter) error {
+	return nil
+}
+
+func (sponsorBlockHandler) OnChapterStarted(player *gavalink.Player, chapter gavalink.Chapter) error {
+	return nil
+}
+
+func TestSponsorBlockEvents(t *testing.T) {
+	srv := newTestServer(t)
+	handler := sponsorBlockHandler{
+		loaded:  make(chan []gavalink.Segment, 1),
+		skipped: make(chan gavalink.Segment, 1),
+	}
+	lavalink, _ := newTestPlayer(t, srv, handler)
+	if err := lavalink.Use(gavalink.SponsorBlock{}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Send(t, `{"op":"event","type":"SegmentsLoaded","guildId":"1","segments":[{"category":"intro","start":0,"end":5000}]}`)
+	srv.Send(t, `{"op":"event","type":"SegmentSkipped","guildId":"1","segment":{"category":"sponsor","start":60000,"end":90000}}`)
+
+	select {
+	case segments := <-handler.loaded:
+		want := []gavalink.Segment{{Category: gavalink.SegmentIntro, Start: 0, End: 5000}}
+		if !reflect.DeepEqual(segments, want) {
+			t.Errorf("got segments %+v, want %+v", segments, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("SegmentsLoaded wasn't dispatched")
+	}
+	select {
+	case segment := <-handler.skipped:
+		want := gavalink.Segment{Category: gavalink.SegmentSponsor, Start: 60000, End: 90000}
+		if segment != want {
+			t.Errorf("got segment %+v, want %+v", segment, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("SegmentSkipped wasn't dispatched")
+	}
+}
+
+func TestSponsorBlockCategories(t *testing.T) {
+	srv := newTestServer(t)
+
+	var mu sync.Mutex
+	var categories []string
+	srv.Handle("/v3/sessions/session-a/players/1/sponsorblock/categories", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(categories)
+		case http.MethodPut:
+			json.NewDecoder(r.Body).Decode(&categories)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			categories = nil
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	_, player := newTestPlayer(t, srv, gavalink.DummyEventHandler{})
+	ctx := context.Background()
+	if err := player.SetSponsorBlockCategories(ctx, gavalink.SegmentSponsor); !errors.Is(err, gavalink.ErrNoSession) {
+		t.Errorf("setting categories without a session returned %v", err)
+	}
+
+	srv.Send(t, `{"op":"ready","resumed":false,"sessionId":"session-a"}`)
+	waitFor(t, "the session", func() bool { return player.Node().SessionID() != "" })
+
+	if err := player.SetSponsorBlockCategories(ctx, gavalink.SegmentSponsor, gavalink.SegmentIntro); err != nil {
+		t.Fatal(err)
+	}
+	got, err := player.GetSponsorBlockCategories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{gavalink.SegmentSponsor, gavalink.SegmentIntro}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got categories %v, want %v", got, want)
+	}
+	if err = player.ClearSponsorBlockCategories(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = player.GetSponsorBlockCategories(ctx); err != nil || len(got) != 0 {
+		t.Errorf("got categories %v, %v after clearing them", got, err)
+	}
+}
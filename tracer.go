@@ -0,0 +1,65 @@
+package gavalink
+
+import (
+	"context"
+)
+
+// Attribute keys gavalink annotates its spans with
+const (
+	AttrNode     = "gavalink.node"
+	AttrGuild    = "gavalink.guild_id"
+	AttrOp       = "gavalink.op"
+	AttrLoadType = "gavalink.load_type"
+)
+
+// Attribute is a key-value pair annotating a span
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Tracer starts spans around gavalink's REST calls and player operations
+//
+// See the tracing package for an OpenTelemetry implementation.
+type Tracer interface {
+	// Start starts a span as a child of any span in ctx, and returns a
+	// context carrying the new span
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is a traced operation started by a Tracer
+type Span interface {
+	// SetAttributes annotates the span
+	SetAttributes(attrs ...Attribute)
+	// End ends the span, recording err if it isn't nil
+	End(err error)
+}
+
+// nopTracer is used by managers without a tracer
+type nopTracer struct{}
+
+type nopSpan struct{}
+
+func (nopTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+func (nopSpan) SetAttributes(attrs ...Attribute) {}
+func (nopSpan) End(err error)                    {}
+
+// SetTracer sets the tracer this manager starts spans with
+func (lavalink *Lavalink) SetTracer(tracer Tracer) {
+	lavalink.mu.Lock()
+	lavalink.trc = tracer
+	lavalink.mu.Unlock()
+}
+
+func (lavalink *Lavalink) tracer() Tracer {
+	lavalink.mu.RLock()
+	defer lavalink.mu.RUnlock()
+
+	if lavalink.trc == nil {
+		return nopTracer{}
+	}
+	return lavalink.trc
+}
@@ -0,0 +1,69 @@
+// Package tracing traces gavalink with OpenTelemetry
+//
+// Spans are exported by whichever exporter the TracerProvider is
+// configured with:
+//
+//	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+//	lavalink.SetTracer(tracing.New(provider))
+//
+// The package is a module of its own, so that only programs importing it
+// depend on OpenTelemetry.
+package tracing
+
+import (
+	"context"
+
+	"github.com/foxbot/gavalink"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the OpenTelemetry tracer gavalink
+// uses
+const InstrumentationName = "github.com/foxbot/gavalink"
+
+// Tracer adapts an OpenTelemetry tracer to a gavalink.Tracer
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// New creates a Tracer starting spans with provider
+func New(provider trace.TracerProvider) *Tracer {
+	return &Tracer{
+		tracer: provider.Tracer(InstrumentationName),
+	}
+}
+
+// Start starts a span as a child of any span in ctx
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...gavalink.Attribute) (context.Context, gavalink.Span) {
+	ctx, span := t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(convert(attrs)...),
+	)
+	return ctx, otelSpan{span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s otelSpan) SetAttributes(attrs ...gavalink.Attribute) {
+	s.span.SetAttributes(convert(attrs)...)
+}
+
+func (s otelSpan) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+func convert(attrs []gavalink.Attribute) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, len(attrs))
+	for i, a := range attrs {
+		kvs[i] = attribute.String(a.Key, a.Value)
+	}
+	return kvs
+}
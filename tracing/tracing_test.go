@@ -0,0 +1,90 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/foxbot/gavalink"
+	"github.com/foxbot/gavalink/tracing"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestLoadTracksSpan(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/loadtracks" {
+			w.Write([]byte(`{"loadType":"NO_MATCHES","tracks":[]}`))
+			return
+		}
+		header := http.Header{}
+		header.Set("Lavalink-Api-Version", "3")
+		ws, err := upgrader.Upgrade(w, r, header)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	lavalink := gavalink.NewLavalink("1", "1")
+	defer lavalink.Close(context.Background(), false)
+	lavalink.SetTracer(tracing.New(provider))
+	err := lavalink.AddNodes(gavalink.NodeConfig{
+		Name:      "a",
+		REST:      srv.URL,
+		WebSocket: "ws" + strings.TrimPrefix(srv.URL, "http"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := lavalink.Node("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "play command")
+	_, err = node.LoadTracksContext(ctx, "ytsearch:lofi")
+	parent.End()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "gavalink.LoadTracks" {
+		t.Errorf("span name %s", span.Name)
+	}
+	if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Error("span isn't a child of the caller's span")
+	}
+
+	want := map[attribute.Key]string{
+		gavalink.AttrNode:     "a",
+		gavalink.AttrLoadType: gavalink.NoMatches,
+	}
+	for _, kv := range span.Attributes {
+		if v, ok := want[kv.Key]; ok && kv.Value.AsString() == v {
+			delete(want, kv.Key)
+		}
+	}
+	if len(want) > 0 {
+		t.Errorf("span is missing attributes %v", want)
+	}
+}
@@ -0,0 +1,184 @@
+package gavalink
+
+import (
+	"context"
+)
+
+// voiceConn tracks the voice events Discord sent for a guild this bot
+// is connected to
+type voiceConn struct {
+	sessionID string
+	server    *VoiceServerUpdate
+}
+
+type voiceJoin struct {
+	handler EventHandler
+	done    chan voiceJoinResult
+}
+
+type voiceJoinResult struct {
+	player *Player
+	err    error
+}
+
+// SetGateway sets the gateway used to join and leave voice channels
+//
+// gavalink registers itself for voice events on the gateway; once set,
+// Join and Leave manage players without any further wiring.
+func (lavalink *Lavalink) SetGateway(gateway VoiceGateway) {
+	lavalink.voiceMu.Lock()
+	lavalink.gateway = gateway
+	lavalink.voiceMu.Unlock()
+
+	gateway.HandleVoiceEvents(lavalink.onVoiceState, lavalink.onVoiceServer)
+}
+
+// Join connects to a voice channel and creates a player for it
+//
+// Join sends a Voice State Update through the gateway and blocks until
+// Discord has sent the events needed to create the player, or ctx is
+// done.
+func (lavalink *Lavalink) Join(ctx context.Context, guildID string, channelID string, handler EventHandler) (*Player, error) {
+	if handler == nil {
+		return nil, ErrNilHandler
+	}
+
+	lavalink.voiceMu.Lock()
+	gateway := lavalink.gateway
+	if gateway == nil {
+		lavalink.voiceMu.Unlock()
+		return nil, ErrNoGateway
+	}
+	join := &voiceJoin{
+		handler: handler,
+		done:    make(chan voiceJoinResult, 1),
+	}
+	lavalink.joins[guildID] = join
+	lavalink.voiceMu.Unlock()
+
+	if err := gateway.UpdateVoiceState(guildID, channelID, false, false); err != nil {
+		lavalink.cancelJoin(guildID, join)
+		return nil, err
+	}
+
+	select {
+	case res := <-join.done:
+		return res.player, res.err
+	case <-ctx.Done():
+		lavalink.cancelJoin(guildID, join)
+		return nil, ctx.Err()
+	}
+}
+
+// Leave destroys the guild's player, if any, and disconnects from voice
+func (lavalink *Lavalink) Leave(guildID string) error {
+	lavalink.voiceMu.Lock()
+	gateway := lavalink.gateway
+	delete(lavalink.voice, guildID)
+	lavalink.voiceMu.Unlock()
+
+	if gateway == nil {
+		return ErrNoGateway
+	}
+
+	if p, err := lavalink.GetPlayer(guildID); err == nil {
+		if err = p.Destroy(); err != nil {
+			return err
+		}
+	}
+
+	return gateway.UpdateVoiceState(guildID, "", false, false)
+}
+
+func (lavalink *Lavalink) cancelJoin(guildID string, join *voiceJoin) {
+	lavalink.voiceMu.Lock()
+	if lavalink.joins[guildID] == join {
+		delete(lavalink.joins, guildID)
+	}
+	lavalink.voiceMu.Unlock()
+}
+
+func (lavalink *Lavalink) onVoiceState(state VoiceState) {
+	if state.UserID != lavalink.userID {
+		return
+	}
+
+	if state.ChannelID == "" {
+		lavalink.voiceMu.Lock()
+		delete(lavalink.voice, state.GuildID)
+		lavalink.voiceMu.Unlock()
+
+		// we were disconnected, the player can't be used anymore
+		if p, err := lavalink.GetPlayer(state.GuildID); err == nil {
+			if err = p.Destroy(); err != nil {
+				p.logger().Error("couldn't destroy disconnected player", "err", err)
+			}
+		}
+		return
+	}
+
+	lavalink.voiceMu.Lock()
+	conn := lavalink.voiceConn(state.GuildID)
+	conn.sessionID = state.SessionID
+	lavalink.voiceMu.Unlock()
+
+	lavalink.connectVoice(state.GuildID)
+}
+
+func (lavalink *Lavalink) onVoiceServer(event VoiceServerUpdate) {
+	lavalink.voiceMu.Lock()
+	conn := lavalink.voiceConn(event.GuildID)
+	conn.server = &event
+	lavalink.voiceMu.Unlock()
+
+	lavalink.connectVoice(event.GuildID)
+}
+
+// voiceConn must be called with voiceMu held
+func (lavalink *Lavalink) voiceConn(guildID string) *voiceConn {
+	conn, ok := lavalink.voice[guildID]
+	if !ok {
+		conn = new(voiceConn)
+		lavalink.voice[guildID] = conn
+	}
+	return conn
+}
+
+// connectVoice creates or updates the guild's player once both the
+// session and the voice server are known
+func (lavalink *Lavalink) connectVoice(guildID string) {
+	lavalink.voiceMu.Lock()
+	conn, ok := lavalink.voice[guildID]
+	if !ok || conn.sessionID == "" || conn.server == nil {
+		lavalink.voiceMu.Unlock()
+		return
+	}
+	sessionID, server := conn.sessionID, *conn.server
+	join := lavalink.joins[guildID]
+	delete(lavalink.joins, guildID)
+	lavalink.voiceMu.Unlock()
+
+	if p, err := lavalink.GetPlayer(guildID); err == nil {
+		err = p.Forward(sessionID, server)
+		if err != nil {
+			p.logger().Error("couldn't forward voice server update", "err", err)
+		}
+		if join != nil {
+			join.done <- voiceJoinResult{p, err}
+		}
+		return
+	}
+
+	// not a voice connection we asked for
+	if join == nil {
+		return
+	}
+
+	node, err := lavalink.BestNode(server.Endpoint)
+	if err != nil {
+		join.done <- voiceJoinResult{nil, err}
+		return
+	}
+	p, err := node.CreatePlayer(guildID, sessionID, server, join.handler)
+	join.done <- voiceJoinResult{p, err}
+}
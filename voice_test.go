@@ -0,0 +1,158 @@
+package gavalink_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/foxbot/gavalink"
+)
+
+// testGateway is a fake Discord gateway which records the voice state
+// updates it's asked to send
+type testGateway struct {
+	mu       sync.Mutex
+	updates  []string
+	onState  func(gavalink.VoiceState)
+	onServer func(gavalink.VoiceServerUpdate)
+}
+
+func (g *testGateway) UpdateVoiceState(guildID string, channelID string, selfMute bool, selfDeaf bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.updates = append(g.updates, guildID+"/"+channelID)
+	return nil
+}
+
+func (g *testGateway) HandleVoiceEvents(onState func(gavalink.VoiceState), onServer func(gavalink.VoiceServerUpdate)) {
+	g.onState = onState
+	g.onServer = onServer
+}
+
+// Updates returns the voice state updates sent, as guild/channel
+func (g *testGateway) Updates() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return slices.Clone(g.updates)
+}
+
+func newTestGateway(t *testing.T, srv *testServer) (*gavalink.Lavalink, *testGateway) {
+	t.Helper()
+	lavalink := gavalink.NewLavalink("1", "1")
+	t.Cleanup(func() { lavalink.Close(context.Background(), false) })
+	if err := lavalink.AddNodes(testNodeConfig(srv, "a")); err != nil {
+		t.Fatal(err)
+	}
+	gateway := new(testGateway)
+	lavalink.SetGateway(gateway)
+	return lavalink, gateway
+}
+
+func TestJoin(t *testing.T) {
+	srv := newTestServer(t)
+	lavalink, gateway := newTestGateway(t, srv)
+
+	type result struct {
+		player *gavalink.Player
+		err    error
+	}
+	joined := make(chan result, 1)
+	go func() {
+		player, err := lavalink.Join(context.Background(), "2", "3", gavalink.DummyEventHandler{})
+		joined <- result{player, err}
+	}()
+	waitFor(t, "the voice state update", func() bool { return len(gateway.Updates()) == 1 })
+
+	// other users' voice states are ignored
+	gateway.onState(gavalink.VoiceState{GuildID: "2", ChannelID: "3", UserID: "4", SessionID: "other"})
+	gateway.onState(gavalink.VoiceState{GuildID: "2", ChannelID: "3", UserID: "1", SessionID: "session"})
+	gateway.onServer(gavalink.VoiceServerUpdate{GuildID: "2", Endpoint: "c-ams05-1234.discord.media:443", Token: "token"})
+
+	res := <-joined
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if res.player.GuildID() != "2" {
+		t.Errorf("joined guild %s, want 2", res.player.GuildID())
+	}
+	if player, err := lavalink.GetPlayer("2"); err != nil || player != res.player {
+		t.Errorf("got player %v, %v, want the joined player", player, err)
+	}
+	waitFor(t, "the voice update", func() bool { return slices.Contains(srv.Ops(), "voiceUpdate") })
+	if frames := srv.Frames(); !slices.ContainsFunc(frames, func(frame string) bool {
+		return frame == `{"op":"voiceUpdate","guildId":"2","sessionId":"session","event":{"guild_id":"2","endpoint":"c-ams05-1234.discord.media:443","token":"token"}}`
+	}) {
+		t.Errorf("node received %v, want the voice update of the session", frames)
+	}
+
+	if err := lavalink.Leave("2"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"2/3", "2/"}; !slices.Equal(gateway.Updates(), want) {
+		t.Errorf("sent voice state updates %v, want %v", gateway.Updates(), want)
+	}
+	waitFor(t, "the player to be destroyed", func() bool { return slices.Contains(srv.Ops(), "destroy") })
+	if _, err := lavalink.GetPlayer("2"); err == nil {
+		t.Error("player survived Leave")
+	}
+}
+
+func TestVoiceDisconnect(t *testing.T) {
+	srv := newTestServer(t)
+	lavalink, gateway := newTestGateway(t, srv)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := lavalink.Join(context.Background(), "2", "3", gavalink.DummyEventHandler{})
+		done <- err
+	}()
+	waitFor(t, "the voice state update", func() bool { return len(gateway.Updates()) == 1 })
+	// the server update may come first
+	gateway.onServer(gavalink.VoiceServerUpdate{GuildID: "2", Endpoint: "endpoint", Token: "token"})
+	gateway.onState(gavalink.VoiceState{GuildID: "2", ChannelID: "3", UserID: "1", SessionID: "session"})
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// being disconnected destroys the player
+	gateway.onState(gavalink.VoiceState{GuildID: "2", UserID: "1", SessionID: "session"})
+	if _, err := lavalink.GetPlayer("2"); err == nil {
+		t.Error("player survived the disconnect")
+	}
+}
+
+func TestJoinValidation(t *testing.T) {
+	srv := newTestServer(t)
+	lavalink := gavalink.NewLavalink("1", "1")
+	defer lavalink.Close(context.Background(), false)
+	if err := lavalink.AddNodes(testNodeConfig(srv, "a")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := lavalink.Join(context.Background(), "2", "3", gavalink.DummyEventHandler{}); !errors.Is(err, gavalink.ErrNoGateway) {
+		t.Errorf("joining without a gateway returned %v", err)
+	}
+	if err := lavalink.Leave("2"); !errors.Is(err, gavalink.ErrNoGateway) {
+		t.Errorf("leaving without a gateway returned %v", err)
+	}
+
+	gateway := new(testGateway)
+	lavalink.SetGateway(gateway)
+	if _, err := lavalink.Join(context.Background(), "2", "3", nil); !errors.Is(err, gavalink.ErrNilHandler) {
+		t.Errorf("joining without a handler returned %v", err)
+	}
+
+	// a join which never completes gives up with its context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := lavalink.Join(ctx, "2", "3", gavalink.DummyEventHandler{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled join returned %v", err)
+	}
+	gateway.onState(gavalink.VoiceState{GuildID: "2", ChannelID: "3", UserID: "1", SessionID: "session"})
+	gateway.onServer(gavalink.VoiceServerUpdate{GuildID: "2", Endpoint: "endpoint", Token: "token"})
+	if _, err := lavalink.GetPlayer("2"); err == nil {
+		t.Error("canceled join created a player")
+	}
+}